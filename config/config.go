@@ -13,18 +13,29 @@ var chains map[string]ChainInfo
 type ChainInfo struct {
 	ChainName       string
 	Rpc             string
-	CurrancySymbol  string
 	ChainId         int             `json:"ChainId"`
+	NativeCurrency  NativeCurrency  `json:"NativeCurrency"`
+	WETH            string          `json:"WETH"` // wrapped native token 地址
 	SoDiamond       string          `json:"SoDiamond"`
 	StargateChainId int             `json:"StargateChainId"`
+	StargateRouter  string          `json:"StargateRouter"`
 	UniswapRouter   []UniswapRouter `json:"UniswapRouter"`
 	StargatePool    []Pool          `json:"StargatePool"`
 }
 
+// NativeCurrency 链的原生币，SoData/SwapData 中用 0 地址表示
+type NativeCurrency struct {
+	Name     string `json:"Name"`
+	Symbol   string `json:"Symbol"`
+	Decimals int    `json:"Decimals"`
+}
+
 type UniswapRouter struct {
 	Name          string `json:"Name"`
 	RouterAddress string `json:"RouterAddress"`
 	Type          string `json:"Type"`
+	FeeTiers      []int  `json:"FeeTiers"`                  // ISwapRouter factory 启用的手续费档位，为空时使用 Uniswap V3 标准档位
+	Quoter        string `json:"QuoterAddressForUniswapV3"` // ISwapRouter 对应的 Uniswap V3 Quoter
 }
 
 type Pool struct {
@@ -32,6 +43,17 @@ type Pool struct {
 	Decimal      int
 	TokenAddress string
 	TokenName    string
+	ChainPath    [][]int // 支持的目标 pool，每项为 [目标链 StargateChainId, 目标 PoolId]
+}
+
+// SupportsPath pool 是否可以跨链到目标链的目标 pool
+func (p Pool) SupportsPath(dstStargateChainId, dstPoolId int) bool {
+	for _, path := range p.ChainPath {
+		if len(path) == 2 && path[0] == dstStargateChainId && path[1] == dstPoolId {
+			return true
+		}
+	}
+	return false
 }
 
 func init() {
@@ -49,19 +71,10 @@ func init() {
 		42161: "https://rpc.ankr.com/arbitrum",         // arbitrum
 		10:    "https://mainnet.optimism.io",           // op
 	}
-	currancySymbol := map[int]string{
-		1:     "ETH",         // eth
-		56:    "BNB",         // bsc
-		43114: "AVAX",        // avax-c
-		137:   "MATIC",       // polygon
-		42161: "ARBITRUMETH", // arbitrum
-		10:    "OPETH",       // op
-	}
 
 	for k, v := range chains {
 		v.ChainName = k
 		v.Rpc = rpcs[v.ChainId]
-		v.CurrancySymbol = currancySymbol[v.ChainId]
 		chains[k] = v
 	}
 }
@@ -84,10 +97,21 @@ func GetChainByChainId(chainId int) *ChainInfo {
 	return nil
 }
 
+// GetStargatePool 获取链上的 stargate pool，不存在时返回 nil
+func GetStargatePool(chain *ChainInfo, poolId int) *Pool {
+	for _, pool := range chain.StargatePool {
+		if pool.PoolId == poolId {
+			return &pool
+		}
+	}
+	return nil
+}
+
 func GetAllChains() map[string]ChainInfo {
 	return chains
 }
 
+// GetChainByName 按链的别名查询主网，其他名称按配置中的链名查询，如 bsc-test
 func GetChainByName(name string) *ChainInfo {
 	var chainId int
 	switch name {
@@ -103,6 +127,11 @@ func GetChainByName(name string) *ChainInfo {
 		chainId = 42161
 	case "op", "optimism":
 		chainId = 10
+	default:
+		if c, ok := chains[name]; ok {
+			return &c
+		}
+		return nil
 	}
 	return GetChainByChainId(chainId)
 }
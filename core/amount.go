@@ -0,0 +1,57 @@
+package core
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// AmountFormat token 数量的输出格式
+type AmountFormat struct {
+	Precision int  // 最多保留的小数位数（截断），0 表示保留全部精度
+	Thousands bool // 整数部分使用千分位分隔
+	ShowRaw   bool // 同时输出链上的原始整数
+}
+
+// Format 按 token 精度精确换算数量，不经过 float64，去掉小数末尾的 0
+func (f AmountFormat) Format(amount *big.Int, token Token) string {
+	if nil == amount {
+		amount = big.NewInt(0)
+	}
+	a := decimal.NewFromBigInt(amount, -int32(token.Decimals))
+	if f.Precision > 0 {
+		a = a.Truncate(int32(f.Precision))
+	}
+	s := a.String()
+	if f.Thousands {
+		s = groupThousands(s)
+	}
+	if token.Symbol != "" {
+		s = s + " " + token.Symbol
+	}
+	if f.ShowRaw {
+		s = s + " (raw " + amount.String() + ")"
+	}
+	return s
+}
+
+// groupThousands 1234567.89 -> 1,234,567.89
+func groupThousands(s string) string {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i:]
+	}
+	var b strings.Builder
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	return sign + b.String() + fracPart
+}
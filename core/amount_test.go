@@ -0,0 +1,60 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestAmountFormat_Format(t *testing.T) {
+	usdc := Token{Symbol: "USDC", Decimals: 6}
+	weth := Token{Symbol: "WETH", Decimals: 18}
+	large, _ := new(big.Int).SetString("123456789012345678901234567", 10)
+
+	tests := []struct {
+		name   string
+		format AmountFormat
+		amount *big.Int
+		token  Token
+		want   string
+	}{
+		{
+			name:   "trim trailing zeros",
+			amount: big.NewInt(3349296000),
+			token:  usdc,
+			want:   "3349.296 USDC",
+		},
+		{
+			name:   "exact 18 decimals",
+			amount: big.NewInt(2000000000000000001),
+			token:  weth,
+			want:   "2.000000000000000001 WETH",
+		},
+		{
+			name:   "large amount",
+			amount: large,
+			token:  weth,
+			want:   "123456789.012345678901234567 WETH",
+		},
+		{
+			name:   "precision and thousands",
+			format: AmountFormat{Precision: 4, Thousands: true},
+			amount: large,
+			token:  weth,
+			want:   "123,456,789.0123 WETH",
+		},
+		{
+			name:   "raw",
+			format: AmountFormat{ShowRaw: true},
+			amount: big.NewInt(0),
+			token:  usdc,
+			want:   "0 USDC (raw 0)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.format.Format(tt.amount, tt.token); got != tt.want {
+				t.Errorf("Format() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
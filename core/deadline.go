@@ -0,0 +1,87 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/xiang-xx/oparse/config"
+
+	"github.com/fatih/color"
+)
+
+// stargateDeliveryTimes 从源链发出的 Stargate 消息到达目标链的大致时间，主要取决于 LayerZero 在源链上等待的确认数
+var stargateDeliveryTimes = map[int]time.Duration{
+	1:     5 * time.Minute,  // eth，15 个确认
+	56:    2 * time.Minute,  // bsc，20 个确认
+	43114: 2 * time.Minute,  // avax-c，12 个确认
+	137:   20 * time.Minute, // polygon，512 个确认
+	42161: 5 * time.Minute,  // arbitrum
+	10:    5 * time.Minute,  // op
+}
+
+const defaultStargateDeliveryTime = 5 * time.Minute
+
+// expectedDeliveryTime 从 fromChain 发出的 Stargate 消息预计多久到达目标链
+func expectedDeliveryTime(fromChain *config.ChainInfo) time.Duration {
+	if t, ok := stargateDeliveryTimes[fromChain.ChainId]; ok {
+		return t
+	}
+	return defaultStargateDeliveryTime
+}
+
+// deadlineRef swap deadline 的参照时间，at 为交易所在区块的时间（未打包时为当前时间），
+// 目标链 swap 在 Stargate 消息到达后才执行，delivery 为预计的到账时间，源链 swap 为 0
+type deadlineRef struct {
+	at       time.Time
+	delivery time.Duration
+}
+
+// check deadline 早于参照时间时 swap 必然失败，目标链 swap 的 deadline 早于预计到账时间时可能失败
+func (r deadlineRef) check(deadline *big.Int) (Severity, string, bool) {
+	if nil == deadline {
+		return SeverityInfo, "", false
+	}
+	if deadline.Cmp(big.NewInt(r.at.Unix())) < 0 {
+		return SeverityError, fmt.Sprintf("deadline %s is already past", formatTimestamp(deadline)), true
+	}
+	if r.delivery > 0 && deadline.Cmp(big.NewInt(r.at.Add(r.delivery).Unix())) < 0 {
+		return SeverityWarning, fmt.Sprintf("deadline %s is likely to expire before the Stargate message lands in ~%s", formatTimestamp(deadline), r.delivery), true
+	}
+	return SeverityInfo, "", false
+}
+
+// describe deadline 相对参照时间的描述，如 in 20m0s、expired 5m0s ago
+func (r deadlineRef) describe(deadline *big.Int) string {
+	if !deadline.IsInt64() {
+		return "never expires"
+	}
+	d := time.Unix(deadline.Int64(), 0).Sub(r.at)
+	if d < 0 {
+		return "expired " + (-d).String() + " ago"
+	}
+	return "in " + d.String()
+}
+
+func formatTimestamp(timestamp *big.Int) string {
+	if !timestamp.IsInt64() {
+		return timestamp.String()
+	}
+	return time.Unix(timestamp.Int64(), 0).UTC().Format(time.RFC3339)
+}
+
+// printDeadline 输出 swap 的 deadline 及相对交易时间的剩余时间，过期或可能在到账前过期时输出警告
+func printDeadline(w io.Writer, deadline *big.Int, ref deadlineRef) {
+	if nil == deadline {
+		return
+	}
+	printAlignLine(w, "", "Deadline      "+formatTimestamp(deadline)+" ("+ref.describe(deadline)+")")
+	if severity, message, ok := ref.check(deadline); ok {
+		if severity == SeverityError {
+			printAlignLine(w, "", color.HiRedString("error: %s", message))
+		} else {
+			printAlignLine(w, "", color.HiYellowString("warning: %s", message))
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func Test_deadlineRef_check(t *testing.T) {
+	at := time.Unix(1660000000, 0)
+	tests := []struct {
+		name     string
+		ref      deadlineRef
+		deadline int64
+		want     Severity
+		wantOk   bool
+	}{
+		{name: "src valid", ref: deadlineRef{at: at}, deadline: at.Unix() + 60},
+		{name: "src expired", ref: deadlineRef{at: at}, deadline: at.Unix() - 1, want: SeverityError, wantOk: true},
+		{name: "dst after delivery", ref: deadlineRef{at: at, delivery: 5 * time.Minute}, deadline: at.Unix() + 600},
+		{name: "dst before delivery", ref: deadlineRef{at: at, delivery: 5 * time.Minute}, deadline: at.Unix() + 60, want: SeverityWarning, wantOk: true},
+		{name: "dst expired", ref: deadlineRef{at: at, delivery: 5 * time.Minute}, deadline: at.Unix() - 1, want: SeverityError, wantOk: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, ok := tt.ref.check(big.NewInt(tt.deadline))
+			if ok != tt.wantOk || (ok && got != tt.want) {
+				t.Errorf("check() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/xiang-xx/oparse/config"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Decoder 解析交易，每条链只建立一个 rpc 连接，在整个解析过程（包括批量解析多笔交易）中复用
+// 使用完毕后需要调用 Close 关闭所有连接
+type Decoder struct {
+	withDetail   bool
+	callTimeout  time.Duration
+	amountFormat AmountFormat
+
+	lock    sync.Mutex
+	clients map[string]*rpc.Client
+}
+
+type Options struct {
+	WithDetail   bool          // 输出详细信息
+	CallTimeout  time.Duration // 单个 rpc 请求的超时时间，0 表示不限制
+	AmountFormat AmountFormat  // token 数量的输出格式
+}
+
+func NewDecoder(opts Options) *Decoder {
+	return &Decoder{
+		withDetail:   opts.WithDetail,
+		callTimeout:  opts.CallTimeout,
+		amountFormat: opts.AmountFormat,
+		clients:      make(map[string]*rpc.Client, 0),
+	}
+}
+
+// callContext 为单个 rpc 请求设置超时
+func (d *Decoder) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if d.callTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d.callTimeout)
+}
+
+// rpcClient 获取链的 rpc client，不存在时建立连接
+func (d *Decoder) rpcClient(chain *config.ChainInfo) (*rpc.Client, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if c, ok := d.clients[chain.ChainName]; ok {
+		return c, nil
+	}
+	c, err := rpc.DialContext(context.Background(), chain.Rpc)
+	if err != nil {
+		return nil, err
+	}
+	d.clients[chain.ChainName] = c
+	return c, nil
+}
+
+func (d *Decoder) client(chain *config.ChainInfo) (*ethclient.Client, error) {
+	c, err := d.rpcClient(chain)
+	if err != nil {
+		return nil, err
+	}
+	return ethclient.NewClient(c), nil
+}
+
+// Close 关闭所有 rpc 连接
+func (d *Decoder) Close() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	for name, c := range d.clients {
+		c.Close()
+		delete(d.clients, name)
+	}
+}
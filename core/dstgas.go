@@ -0,0 +1,157 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/xiang-xx/oparse/config"
+	"github.com/xiang-xx/oparse/xabi"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fatih/color"
+)
+
+// estimateSgReceiveGas 在目标链上用 sgReceiveForGas 预估 SoDiamond sgReceive 执行目标链 swap 所需的 gas
+func (d *Decoder) estimateSgReceiveGas(ctx context.Context, toChain *config.ChainInfo, input *SoSwapViaStargateInputData) (uint64, error) {
+	data, err := xabi.SoDiamond.Pack("sgReceiveForGas", input.SoData, input.StargateData.DstStargatePoolId, input.SwapDataDst)
+	if err != nil {
+		return 0, err
+	}
+	client, err := d.client(toChain)
+	if err != nil {
+		return 0, err
+	}
+	to := common.HexToAddress(toChain.SoDiamond)
+	callCtx, cancel := d.callContext(ctx)
+	defer cancel()
+	gas, err := client.EstimateGas(callCtx, ethereum.CallMsg{To: &to, Data: data})
+	if err != nil {
+		if reason, ok := revertReason(err); ok {
+			return 0, errors.New("sgReceiveForGas reverted: " + reason)
+		}
+		return 0, err
+	}
+	return gas, nil
+}
+
+// dstGasTooLow DstGasForSgReceive 是否低于目标链上预估的 sgReceive gas
+func dstGasTooLow(provided *big.Int, estimated uint64) bool {
+	return provided.Cmp(new(big.Int).SetUint64(estimated)) < 0
+}
+
+// printDstGas 对比 DstGasForSgReceive 与目标链上预估的 sgReceive gas，dstReceipt 不为 nil（指定了目标链交易）时输出实际消耗的 gas，
+// 并检查目标链交易是否触发了 CachedSgReceive（gas 不足或目标链 swap 失败，payload 被缓存）
+func (d *Decoder) printDstGas(ctx context.Context, w io.Writer, toChain *config.ChainInfo, input *SoSwapViaStargateInputData, receipt *types.Receipt) {
+	provided := input.StargateData.DstGasForSgReceive
+	where := "SgReceive"
+	printItem := func(content string) {
+		printAlignLine(w, where, content)
+		where = ""
+	}
+
+	estimated, err := d.estimateSgReceiveGas(ctx, toChain, input)
+	if err != nil {
+		printItem(color.HiYellowString("warning: estimate on %s failed: %s", toChain.ChainName, err))
+	} else {
+		content := fmt.Sprintf("estimated gas %d by sgReceiveForGas on %s, DstGasForSgReceive %s", estimated, toChain.ChainName, provided)
+		if dstGasTooLow(provided, estimated) {
+			printItem(color.HiRedString("%s is too low, the payload may end up as CachedSgReceive", content))
+		} else {
+			printItem(content)
+		}
+	}
+
+	if nil == receipt {
+		return
+	}
+	// 整笔交易的 gas 还包括 LayerZero endpoint、bridge、router 和 pool，不能直接与只覆盖 sgReceive 的 DstGasForSgReceive 对比
+	printItem(fmt.Sprintf("dst tx used %d gas in total including LayerZero and Stargate, status %d", receipt.GasUsed, receipt.Status))
+	if findCachedSgReceive(toChain, receipt) != nil {
+		printItem(color.HiRedString("CachedSgReceive emitted on %s, the payload is cached and needs a retry, see Stuck", toChain.ChainName))
+	}
+}
+
+// dstReceipt 获取 -dst-tx 指定的目标链交易 receipt
+func (d *Decoder) dstReceipt(ctx context.Context, toChain *config.ChainInfo, dstTxHash string) (*types.Receipt, error) {
+	client, err := d.client(toChain)
+	if err != nil {
+		return nil, err
+	}
+	callCtx, cancel := d.callContext(ctx)
+	defer cancel()
+	return client.TransactionReceipt(callCtx, common.HexToHash(dstTxHash))
+}
+
+// dstPacketKeys 目标链交易收到的 LayerZero 消息的 key，来自 UltraLightNodeV2 的 PacketReceived、
+// Stargate router 的 CachedSwapSaved 和 SoDiamond 的 CachedSgReceive
+func dstPacketKeys(toChain *config.ChainInfo, receipt *types.Receipt) []cachedSwapKey {
+	keys := make([]cachedSwapKey, 0)
+	received := xabi.ILayerZeroUltraLightNodeV2.Events["PacketReceived"]
+	for _, log := range receipt.Logs {
+		packet := struct {
+			SrcAddress  []byte
+			Nonce       uint64
+			PayloadHash [32]byte
+		}{}
+		if len(log.Topics) == 3 && unpackEvent(received, log, &packet) {
+			// PacketReceived 的 srcAddress 只有源链 bridge，目标链 bridge 在 indexed 的 dstAddress 中
+			srcAddress := append(append([]byte{}, packet.SrcAddress...), common.BytesToAddress(log.Topics[2].Bytes()).Bytes()...)
+			chainId := uint16(new(big.Int).SetBytes(log.Topics[1].Bytes()).Uint64())
+			keys = append(keys, cachedSwapKey{ChainId: chainId, SrcAddress: srcAddress, Nonce: new(big.Int).SetUint64(packet.Nonce)})
+			continue
+		}
+		saved := cachedSwapSavedEvent{}
+		if unpackEvent(xabi.IStargateRouter.Events["CachedSwapSaved"], log, &saved) {
+			keys = append(keys, cachedSwapKey{ChainId: saved.ChainId, SrcAddress: saved.SrcAddress, Nonce: saved.Nonce})
+		}
+	}
+	if log := findCachedSgReceive(toChain, receipt); log != nil {
+		cached := cachedSgReceiveEvent{}
+		if unpackEvent(xabi.SoDiamond.Events["CachedSgReceive"], log, &cached) {
+			keys = append(keys, cachedSwapKey{ChainId: cached.ChainId, SrcAddress: cached.SrcAddress, Nonce: cached.Nonce})
+		}
+	}
+	return keys
+}
+
+// errDstUnverified 源链 receipt 中没有 UltraLightNodeV2 的 Packet，无法确认目标链交易是否属于源链交易
+var errDstUnverified = errors.New("LayerZero Packet not found in the source receipt, cannot check that the dst tx belongs to this tx")
+
+// matchDstReceipt 检查目标链交易是否收到了源链交易发出的 LayerZero 消息（srcChainId、srcAddress、nonce 相同）
+func matchDstReceipt(toChain *config.ChainInfo, srcReceipt, dstReceipt *types.Receipt) error {
+	if nil == srcReceipt {
+		return errors.New("source tx is not mined yet")
+	}
+	want := stargatePacketKey(toChain, srcReceipt)
+	if nil == want {
+		return errDstUnverified
+	}
+	keys := dstPacketKeys(toChain, dstReceipt)
+	if len(keys) == 0 {
+		return errors.New("no LayerZero message received in the dst tx")
+	}
+	for _, key := range keys {
+		if key.ChainId == want.ChainId && bytes.Equal(key.SrcAddress, want.SrcAddress) && key.Nonce.Cmp(want.Nonce) == 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("dst tx received nonce %s from chain %d, not nonce %s sent by this tx", keys[0].Nonce, keys[0].ChainId, want.Nonce)
+}
+
+// findCachedSgReceive 查找目标链 SoDiamond 触发的 CachedSgReceive 事件
+func findCachedSgReceive(toChain *config.ChainInfo, receipt *types.Receipt) *types.Log {
+	soDiamond := common.HexToAddress(toChain.SoDiamond)
+	event := xabi.SoDiamond.Events["CachedSgReceive"]
+	for _, log := range receipt.Logs {
+		if log.Address == soDiamond && len(log.Topics) > 0 && log.Topics[0] == event.ID {
+			return log
+		}
+	}
+	return nil
+}
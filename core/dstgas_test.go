@@ -0,0 +1,104 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/xiang-xx/oparse/config"
+	"github.com/xiang-xx/oparse/xabi"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func Test_findCachedSgReceive(t *testing.T) {
+	soDiamond := common.HexToAddress("0x2967E7Bb9DaA5711Ac332cAF874BD47ef99B3820")
+	toChain := &config.ChainInfo{SoDiamond: soDiamond.Hex()}
+	event := xabi.SoDiamond.Events["CachedSgReceive"]
+	tests := []struct {
+		name string
+		log  *types.Log
+		want bool
+	}{
+		{name: "emitted by SoDiamond", log: &types.Log{Address: soDiamond, Topics: []common.Hash{event.ID}}, want: true},
+		{name: "emitted by other contract", log: &types.Log{Address: common.HexToAddress("0x1111111111111111111111111111111111111111"), Topics: []common.Hash{event.ID}}},
+		{name: "other event", log: &types.Log{Address: soDiamond, Topics: []common.Hash{transferTopic}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findCachedSgReceive(toChain, &types.Receipt{Logs: []*types.Log{tt.log}}) != nil; got != tt.want {
+				t.Errorf("findCachedSgReceive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_dstGasTooLow(t *testing.T) {
+	tests := []struct {
+		provided  int64
+		estimated uint64
+		want      bool
+	}{
+		{provided: 300000, estimated: 250000, want: false},
+		{provided: 250000, estimated: 250000, want: false},
+		{provided: 200000, estimated: 250000, want: true},
+	}
+	for _, tt := range tests {
+		if got := dstGasTooLow(big.NewInt(tt.provided), tt.estimated); got != tt.want {
+			t.Errorf("dstGasTooLow(%d, %d) = %v, want %v", tt.provided, tt.estimated, got, tt.want)
+		}
+	}
+}
+
+func Test_matchDstReceipt(t *testing.T) {
+	srcBridge := common.HexToAddress("0x296F55F8Fb28E498B858d0BcDA06D955B2Cb3f97")
+	dstBridge := common.HexToAddress("0x9d1B1669c73b033DFe47ae5a0164Ab96df25B944")
+	toChain := &config.ChainInfo{StargateChainId: 106}
+
+	// 源链 Packet：nonce 42，从 stargate chain 101 发往 106
+	packet := []byte{0, 0, 0, 0, 0, 0, 0, 42, 0, 101}
+	packet = append(packet, srcBridge.Bytes()...)
+	packet = append(packet, 0, 106)
+	packet = append(packet, dstBridge.Bytes()...)
+	packet = append(packet, common.BigToHash(big.NewInt(stargateSwapRemote)).Bytes()...)
+	sent := xabi.ILayerZeroUltraLightNodeV2.Events["Packet"]
+	data, err := sent.Inputs.Pack(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcReceipt := &types.Receipt{Logs: []*types.Log{{Topics: []common.Hash{sent.ID}, Data: data}}}
+
+	received := xabi.ILayerZeroUltraLightNodeV2.Events["PacketReceived"]
+	packetReceived := func(nonce uint64) *types.Log {
+		data, err := received.Inputs.NonIndexed().Pack(srcBridge.Bytes(), nonce, [32]byte{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &types.Log{
+			Topics: []common.Hash{received.ID, common.BigToHash(big.NewInt(101)), common.BytesToHash(dstBridge.Bytes())},
+			Data:   data,
+		}
+	}
+	tests := []struct {
+		name       string
+		srcReceipt *types.Receipt
+		dstLogs    []*types.Log
+		wantErr    bool
+	}{
+		{name: "same nonce", srcReceipt: srcReceipt, dstLogs: []*types.Log{packetReceived(42)}},
+		{name: "other nonce", srcReceipt: srcReceipt, dstLogs: []*types.Log{packetReceived(43)}, wantErr: true},
+		{name: "no LayerZero message", srcReceipt: srcReceipt, wantErr: true},
+		{name: "source not mined", dstLogs: []*types.Log{packetReceived(42)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := matchDstReceipt(toChain, tt.srcReceipt, &types.Receipt{Logs: tt.dstLogs})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("matchDstReceipt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+	if err := matchDstReceipt(toChain, &types.Receipt{}, &types.Receipt{}); err != errDstUnverified {
+		t.Errorf("matchDstReceipt() without Packet error = %v, want %v", err, errDstUnverified)
+	}
+}
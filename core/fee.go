@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/xiang-xx/oparse/config"
+	"github.com/xiang-xx/oparse/xabi"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/fatih/color"
+)
+
+// callSoDiamondUint 在 block 上（nil 表示 latest）调用链上 SoDiamond 返回 uint256 的只读方法
+func (d *Decoder) callSoDiamondUint(ctx context.Context, chain *config.ChainInfo, block *big.Int, method string, args ...interface{}) (*big.Int, error) {
+	input, err := xabi.SoDiamond.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	client, err := d.client(chain)
+	if err != nil {
+		return nil, err
+	}
+	to := common.HexToAddress(chain.SoDiamond)
+	callCtx, cancel := d.callContext(ctx)
+	defer cancel()
+	output, err := client.CallContract(callCtx, ethereum.CallMsg{To: &to, Data: input}, block)
+	if err != nil {
+		if reason, ok := revertReason(err); ok {
+			return nil, errors.New(method + " reverted: " + reason)
+		}
+		return nil, err
+	}
+	values, err := xabi.SoDiamond.Unpack(method, output)
+	if err != nil {
+		return nil, err
+	}
+	return values[0].(*big.Int), nil
+}
+
+// printFeeBreakdown 输出 soSwapViaStargate 的费用：源链 SoDiamond 的 getStargateFee（LayerZero 原生币手续费）与 tx.Value 对比，
+// estimateStargateFinalAmount 预估目标链到账数量，以及目标链 sgReceive 时扣除的 OmniSwap 手续费（getSoFee、getAmountBeforeSoFee）
+func (d *Decoder) printFeeBreakdown(ctx context.Context, w io.Writer, call soCall, fromChain, toChain *config.ChainInfo, input *SoSwapViaStargateInputData) {
+	where := "Fees"
+	printItem := func(name, content string) {
+		printAlignLine(w, where, alignString(name, 11)+"  "+content)
+		where = ""
+	}
+	printFail := func(name string, err error) {
+		printAlignLine(w, where, alignString(name, 11)+"  "+color.HiYellowString("warning: %s", err))
+		where = ""
+	}
+	block := call.srcSwapEnv().quoteBlock
+	native := nativeToken(fromChain)
+
+	// LayerZero 手续费由 tx.Value 中 SoData.Amount 以外的部分支付
+	stargateFee, err := d.callSoDiamondUint(ctx, fromChain, block, "getStargateFee", input.SoData, input.StargateData, input.SwapDataDst)
+	if err != nil {
+		printFail("Stargate", err)
+	} else {
+		paid := new(big.Int).Set(call.value)
+		if isZeroAddress(input.SoData.SendingAssetId) {
+			paid.Sub(paid, input.SoData.Amount)
+		}
+		content := fmt.Sprintf("native fee %s, tx.Value pays %s", d.formatToken(stargateFee, native), d.formatToken(paid, native))
+		if diff := new(big.Int).Sub(paid, stargateFee); diff.Sign() < 0 {
+			printItem("Stargate", color.HiRedString("%s, short by %s", content, d.formatToken(new(big.Int).Neg(diff), native)))
+		} else {
+			printItem("Stargate", fmt.Sprintf("%s, surplus %s", content, d.formatToken(diff, native)))
+		}
+	}
+
+	// 进入 Stargate 的数量：没有源链 swap 时为 SoData.Amount，否则为 receipt 中最后一个 swap 的输出，未打包时以 AmountOutMin 估算
+	bridged, basis := input.SoData.Amount, "SoData.Amount"
+	if n := len(input.SwapDataSrc); n > 0 {
+		bridged, basis = nil, ""
+		if call.receipt != nil {
+			bridged, basis = swapOutputs(fromChain, call.receipt, input.SoData.TransactionId, input.SwapDataSrc)[n-1], "actual SrcSwap output"
+		}
+		if nil == bridged {
+			if router, ok := findRouter(fromChain, input.SwapDataSrc[n-1].CallTo); ok {
+				if swap, err := decodeSwapCall(router, input.SwapDataSrc[n-1]); err == nil && swap != nil {
+					bridged, basis = swap.AmountOutMin, "SrcSwap AmountOutMin"
+				}
+			}
+		}
+	}
+	dstPool := config.GetStargatePool(toChain, int(input.StargateData.DstStargatePoolId.Int64()))
+	if nil == bridged || nil == dstPool {
+		return
+	}
+	dstToken := Token{Symbol: dstPool.TokenName, Decimals: dstPool.Decimal}
+	final, err := d.callSoDiamondUint(ctx, fromChain, block, "estimateStargateFinalAmount", input.StargateData, bridged)
+	if err != nil {
+		printFail("Final", err)
+		return
+	}
+	printItem("Final", fmt.Sprintf("estimated %s on %s after Stargate fees, from %s", d.formatToken(final, dstToken), toChain.ChainName, basis))
+
+	// OmniSwap 手续费在目标链 sgReceive 时扣除
+	soFee, err := d.callSoDiamondUint(ctx, toChain, nil, "getSoFee", final)
+	if err != nil {
+		printFail("OmniSwap", err)
+		return
+	}
+	received := new(big.Int).Sub(final, soFee)
+	// 手续费占预估到账数量的百分比
+	_, percent := slippageMargin(final, received)
+	printItem("OmniSwap", fmt.Sprintf("fee %s (%s%%), %s left for DstSwap or Receiver", d.formatToken(soFee, dstToken), percent, d.formatToken(received, dstToken)))
+	// DstSwap[0].FromAmount 为前端预计扣除手续费后的数量，需要到账 getAmountBeforeSoFee(FromAmount)
+	if len(input.SwapDataDst) == 0 {
+		return
+	}
+	fromAmount := input.SwapDataDst[0].FromAmount
+	needed, err := d.callSoDiamondUint(ctx, toChain, nil, "getAmountBeforeSoFee", fromAmount)
+	if err != nil {
+		printFail("DstSwap", err)
+		return
+	}
+	content := fmt.Sprintf("FromAmount %s needs %s delivered", d.formatToken(fromAmount, dstToken), d.formatToken(needed, dstToken))
+	if final.Cmp(needed) < 0 {
+		printItem("DstSwap", color.HiYellowString("%s, more than the estimated final amount", content))
+	} else {
+		printItem("DstSwap", content)
+	}
+}
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"github.com/xiang-xx/oparse/config"
+
+	"github.com/shopspring/decimal"
+)
+
+// FeeTier Uniswap V3 pool 手续费，单位为百分之一 bip（1e-6），500 表示 0.05%
+type FeeTier int
+
+// feeTierLabels Uniswap V3 标准手续费档位
+var feeTierLabels = map[FeeTier]string{
+	100:   "lowest",
+	500:   "low",
+	3000:  "medium",
+	10000: "high",
+}
+
+// String 500 -> 0.05%
+func (f FeeTier) String() string {
+	return decimal.New(int64(f), -4).String() + "%"
+}
+
+// Label 标准档位的名称，非标准档位返回空
+func (f FeeTier) Label() string {
+	return feeTierLabels[f]
+}
+
+// EnabledOn 手续费档位是否在 router 对应的 factory 上启用，router 未配置 FeeTiers 时按标准档位判断
+func (f FeeTier) EnabledOn(router config.UniswapRouter) bool {
+	if len(router.FeeTiers) == 0 {
+		return f.Label() != ""
+	}
+	for _, tier := range router.FeeTiers {
+		if FeeTier(tier) == f {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,334 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/xiang-xx/oparse/config"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/fatih/color"
+)
+
+// Severity 检查结果的严重程度
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// Finding 一条检查结果
+type Finding struct {
+	Severity Severity
+	Rule     string
+	Message  string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s %s: %s", f.Severity, f.Rule, f.Message)
+}
+
+// HasErrors 是否存在 error 级别的检查结果
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity >= SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// lintContext 规则检查的输入，toChain 为 DestinationChainId 对应的链（未配置时为 nil），
+// stargateData 和 swapDataDst 仅 soSwapViaStargate 有
+type lintContext struct {
+	call         soCall
+	toChain      *config.ChainInfo
+	soData       SoData
+	swapDataSrc  []SwapData
+	stargateData *StargateData
+	swapDataDst  []SwapData
+}
+
+type lintRule struct {
+	name  string
+	check func(c *lintContext) []Finding
+}
+
+// lintRules SoDiamond 调用参数的语义检查规则，用于发现前端构造的错误数据
+var lintRules = []lintRule{
+	{"source-chain", lintSourceChain},
+	{"dst-chain", lintDstChain},
+	{"dst-sodiamond", lintDstSoDiamond},
+	{"chain-path", lintChainPath},
+	{"swap-router", lintSwapRouters},
+	{"min-amount", lintMinAmounts},
+	{"deadline", lintDeadlines},
+	{"dst-gas", lintDstGas},
+	{"receiver", lintReceiver},
+}
+
+// lintSoSwapViaStargate 对 soSwapViaStargate 参数执行所有规则
+func lintSoSwapViaStargate(call soCall, input *SoSwapViaStargateInputData) []Finding {
+	return runLintRules(&lintContext{
+		call:         call,
+		toChain:      config.GetChainByChainId(int(input.SoData.DestinationChainId.Int64())),
+		soData:       input.SoData,
+		swapDataSrc:  input.SwapDataSrc,
+		stargateData: &input.StargateData,
+		swapDataDst:  input.SwapDataDst,
+	})
+}
+
+// lintSwapTokensGeneric 对 swapTokensGeneric 参数执行所有规则，跨链相关的规则会跳过
+func lintSwapTokensGeneric(call soCall, input *GenericInputData) []Finding {
+	return runLintRules(&lintContext{
+		call:        call,
+		soData:      input.SoData,
+		swapDataSrc: input.SwapData,
+	})
+}
+
+func runLintRules(c *lintContext) []Finding {
+	findings := make([]Finding, 0)
+	for _, rule := range lintRules {
+		for _, f := range rule.check(c) {
+			f.Rule = rule.name
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+func lintSourceChain(c *lintContext) []Finding {
+	chain := c.call.chain
+	if c.soData.SourceChainId.Cmp(big.NewInt(int64(chain.ChainId))) != 0 {
+		return []Finding{{Severity: SeverityError, Message: fmt.Sprintf("SourceChainId %s is not %s chain id %d", c.soData.SourceChainId, chain.ChainName, chain.ChainId)}}
+	}
+	return nil
+}
+
+func lintDstChain(c *lintContext) []Finding {
+	if nil == c.stargateData {
+		return nil
+	}
+	if nil == c.toChain {
+		return []Finding{{Severity: SeverityError, Message: fmt.Sprintf("DestinationChainId %s is not configured", c.soData.DestinationChainId)}}
+	}
+	if int(c.stargateData.DstStargateChainId) != c.toChain.StargateChainId {
+		return []Finding{{Severity: SeverityError, Message: fmt.Sprintf("DstStargateChainId %d is not %s stargate chain id %d", c.stargateData.DstStargateChainId, c.toChain.ChainName, c.toChain.StargateChainId)}}
+	}
+	return nil
+}
+
+func lintDstSoDiamond(c *lintContext) []Finding {
+	if nil == c.stargateData || nil == c.toChain {
+		return nil
+	}
+	if c.stargateData.DstSoDiamond != common.HexToAddress(c.toChain.SoDiamond) {
+		return []Finding{{Severity: SeverityError, Message: fmt.Sprintf("DstSoDiamond %s is not %s SoDiamond %s", c.stargateData.DstSoDiamond, c.toChain.ChainName, c.toChain.SoDiamond)}}
+	}
+	return nil
+}
+
+func lintChainPath(c *lintContext) []Finding {
+	if nil == c.stargateData {
+		return nil
+	}
+	chain := c.call.chain
+	stargateData := c.stargateData
+	srcPool := config.GetStargatePool(chain, int(stargateData.SrcStargatePoolId.Int64()))
+	if nil == srcPool {
+		return []Finding{{Severity: SeverityError, Message: fmt.Sprintf("src pool %s is not configured on %s", stargateData.SrcStargatePoolId, chain.ChainName)}}
+	}
+	if !srcPool.SupportsPath(int(stargateData.DstStargateChainId), int(stargateData.DstStargatePoolId.Int64())) {
+		return []Finding{{Severity: SeverityError, Message: fmt.Sprintf("pool %s(%d) has no chain path to stargate chain %d pool %s", srcPool.TokenName, srcPool.PoolId, stargateData.DstStargateChainId, stargateData.DstStargatePoolId)}}
+	}
+	return nil
+}
+
+func lintSwapRouters(c *lintContext) []Finding {
+	findings := make([]Finding, 0)
+	c.eachSwap(func(where string, chain *config.ChainInfo, swapItem SwapData) {
+		router, ok := findRouter(chain, swapItem.CallTo)
+		if !ok {
+			findings = append(findings, Finding{Severity: SeverityWarning, Message: fmt.Sprintf("%s router %s is not configured on %s", where, swapItem.CallTo, chain.ChainName)})
+			return
+		}
+		if _, err := decodeSwapCall(router, swapItem); err != nil {
+			findings = append(findings, Finding{Severity: SeverityError, Message: fmt.Sprintf("%s %s call data: %s", where, router.Name, err)})
+		}
+	})
+	return findings
+}
+
+// lintMinAmounts 最小得到数量为 0 时不做任何滑点保护
+func lintMinAmounts(c *lintContext) []Finding {
+	findings := make([]Finding, 0)
+	c.eachSwapCall(func(where string, call *swapCall) {
+		if call.AmountOutMin.Sign() == 0 {
+			findings = append(findings, Finding{Severity: SeverityWarning, Message: where + " AmountOutMin is 0, no slippage protection"})
+		}
+	})
+	if c.stargateData != nil && c.stargateData.MinAmount.Sign() == 0 {
+		findings = append(findings, Finding{Severity: SeverityWarning, Message: "Stargate MinAmount is 0, no slippage protection"})
+	}
+	return findings
+}
+
+// lintDeadlines 源链 swap 的 deadline 早于交易时间时交易必然失败，目标链 swap 在 Stargate 消息到达时执行，deadline 早于预计到账时间时可能失败
+func lintDeadlines(c *lintContext) []Finding {
+	findings := make([]Finding, 0)
+	src := deadlineRef{at: c.call.time}
+	dst := deadlineRef{at: c.call.time, delivery: expectedDeliveryTime(c.call.chain)}
+	c.eachSwapCall(func(where string, call *swapCall) {
+		ref := src
+		if strings.HasPrefix(where, "DstSwap") {
+			ref = dst
+		}
+		if severity, message, ok := ref.check(call.Deadline); ok {
+			findings = append(findings, Finding{Severity: severity, Message: where + " " + message})
+		}
+	})
+	return findings
+}
+
+// minDstGasForSgReceive 目标链 sgReceive 的粗略 gas 下限，不含 swap 时只需转账，每个 swap 另需 swapGasForSgReceive
+const (
+	minDstGasForSgReceive = 100000
+	swapGasForSgReceive   = 150000
+)
+
+// lintDstGas DstGasForSgReceive 为 0 时 Stargate 无法调用目标链 sgReceive，资产会卡在 Stargate router 中
+func lintDstGas(c *lintContext) []Finding {
+	if nil == c.stargateData {
+		return nil
+	}
+	gas := c.stargateData.DstGasForSgReceive
+	if gas.Sign() == 0 {
+		return []Finding{{Severity: SeverityError, Message: "DstGasForSgReceive is 0"}}
+	}
+	expected := big.NewInt(int64(minDstGasForSgReceive + swapGasForSgReceive*len(c.swapDataDst)))
+	if gas.Cmp(expected) < 0 {
+		return []Finding{{Severity: SeverityWarning, Message: fmt.Sprintf("DstGasForSgReceive %s is lower than %s expected for %d dst swaps", gas, expected, len(c.swapDataDst))}}
+	}
+	return nil
+}
+
+func lintReceiver(c *lintContext) []Finding {
+	if isZeroAddress(c.soData.Receiver) {
+		return []Finding{{Severity: SeverityError, Message: "Receiver is the zero address"}}
+	}
+	if !isZeroAddress(c.call.from) && c.soData.Receiver != c.call.from {
+		return []Finding{{Severity: SeverityInfo, Message: fmt.Sprintf("Receiver %s is not the sender %s", c.soData.Receiver, c.call.from)}}
+	}
+	return nil
+}
+
+// eachSwap 遍历源链和目标链的 swap
+func (c *lintContext) eachSwap(f func(where string, chain *config.ChainInfo, swapItem SwapData)) {
+	for i, swapItem := range c.swapDataSrc {
+		f(fmt.Sprintf("SrcSwap[%d]", i), c.call.chain, swapItem)
+	}
+	if nil == c.toChain {
+		return
+	}
+	for i, swapItem := range c.swapDataDst {
+		f(fmt.Sprintf("DstSwap[%d]", i), c.toChain, swapItem)
+	}
+}
+
+// eachSwapCall 遍历可以解析的 swap 调用，无法解析的由 swap-router 规则报告
+func (c *lintContext) eachSwapCall(f func(where string, call *swapCall)) {
+	c.eachSwap(func(where string, chain *config.ChainInfo, swapItem SwapData) {
+		router, ok := findRouter(chain, swapItem.CallTo)
+		if !ok {
+			return
+		}
+		if call, err := decodeSwapCall(router, swapItem); err == nil && call != nil {
+			f(where, call)
+		}
+	})
+}
+
+// findRouter 查询链上配置的 swap router
+func findRouter(chain *config.ChainInfo, address common.Address) (config.UniswapRouter, bool) {
+	for _, r := range chain.UniswapRouter {
+		if common.HexToAddress(r.RouterAddress) == address {
+			return r, true
+		}
+	}
+	return config.UniswapRouter{}, false
+}
+
+// printFindings 输出检查结果，error 为红色，warning 为黄色，没有结果时输出 ok
+func printFindings(w io.Writer, where string, findings []Finding) {
+	if len(findings) == 0 {
+		printAlignLine(w, where, "ok")
+		return
+	}
+	for i, f := range findings {
+		if i > 0 {
+			where = ""
+		}
+		switch f.Severity {
+		case SeverityError:
+			printAlignLine(w, where, color.HiRedString(f.String()))
+		case SeverityWarning:
+			printAlignLine(w, where, color.HiYellowString(f.String()))
+		default:
+			printAlignLine(w, where, f.String())
+		}
+	}
+}
+
+// LintCalldata 解析未签名的 SoDiamond calldata 并执行所有检查，from 和 value 为将要发送交易的 sender 和 value，
+// 只在 reconcile 数量不一致时查询 token 精度，其余检查不访问 rpc
+func (d *Decoder) LintCalldata(ctx context.Context, w io.Writer, chain *config.ChainInfo, from common.Address, value *big.Int, input []byte) []Finding {
+	call := soCall{chain: chain, from: from, value: value, time: time.Now()}
+	printLine(w)
+	printAlignLine(w, "Chain", chain.ChainName)
+	findings := d.lintCalldata(ctx, call, input)
+	printFindings(w, "Lint", findings)
+	return findings
+}
+
+func (d *Decoder) lintCalldata(ctx context.Context, call soCall, input []byte) []Finding {
+	decoded, err := decodeSoDiamondCall(input)
+	if err != nil {
+		return []Finding{{Severity: SeverityError, Rule: "calldata", Message: err.Error()}}
+	}
+	var findings []Finding
+	var warnings []string
+	switch inputStructData := decoded.(type) {
+	case *SoSwapViaStargateInputData:
+		findings = lintSoSwapViaStargate(call, inputStructData)
+		if toChain := config.GetChainByChainId(int(inputStructData.SoData.DestinationChainId.Int64())); toChain != nil {
+			warnings = d.reconcileStargate(ctx, call.chain, toChain, call.value, inputStructData)
+		}
+	case *GenericInputData:
+		findings = lintSwapTokensGeneric(call, inputStructData)
+		warnings = d.reconcileGeneric(ctx, call.chain, call.value, inputStructData)
+	}
+	for _, warning := range warnings {
+		findings = append(findings, Finding{Severity: SeverityWarning, Rule: "reconcile", Message: warning})
+	}
+	return findings
+}
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/xiang-xx/oparse/config"
+	"github.com/xiang-xx/oparse/xabi"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func Test_lintSoSwapViaStargate(t *testing.T) {
+	chain := config.GetChainByChainId(1)
+	avax := config.GetChainByChainId(43114)
+	now := time.Unix(1660000000, 0)
+	usdc := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	usdt := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	v2Swap := func(amountOutMin int64, deadline int64) SwapData {
+		callData, err := xabi.IUniswapV2Router02.Pack("swapExactTokensForTokens", big.NewInt(1000000), big.NewInt(amountOutMin),
+			[]common.Address{usdt, usdc}, common.HexToAddress(chain.SoDiamond), big.NewInt(deadline))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return SwapData{
+			CallTo:           common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"),
+			SendingAssetId:   usdt,
+			ReceivingAssetId: usdc,
+			FromAmount:       big.NewInt(1000000),
+			CallData:         callData,
+		}
+	}
+	valid := func() *SoSwapViaStargateInputData {
+		return &SoSwapViaStargateInputData{
+			SoData: SoData{
+				Receiver:           common.HexToAddress("0x1111111111111111111111111111111111111111"),
+				SourceChainId:      big.NewInt(1),
+				DestinationChainId: big.NewInt(43114),
+				Amount:             big.NewInt(1000000),
+			},
+			StargateData: StargateData{
+				SrcStargatePoolId:  big.NewInt(1),
+				DstStargateChainId: 6,
+				DstStargatePoolId:  big.NewInt(1),
+				MinAmount:          big.NewInt(990000),
+				DstGasForSgReceive: big.NewInt(200000),
+				DstSoDiamond:       common.HexToAddress(avax.SoDiamond),
+			},
+		}
+	}
+	tests := []struct {
+		name   string
+		modify func(input *SoSwapViaStargateInputData)
+		want   []string
+	}{
+		{
+			name:   "valid",
+			modify: func(input *SoSwapViaStargateInputData) {},
+		},
+		{
+			name: "wrong source chain",
+			modify: func(input *SoSwapViaStargateInputData) {
+				input.SoData.SourceChainId = big.NewInt(56)
+			},
+			want: []string{"source-chain"},
+		},
+		{
+			name: "wrong stargate chain and sodiamond",
+			modify: func(input *SoSwapViaStargateInputData) {
+				input.StargateData.DstStargateChainId = 9
+				input.StargateData.DstSoDiamond = common.Address{}
+			},
+			want: []string{"dst-chain", "dst-sodiamond"},
+		},
+		{
+			name: "unsupported chain path",
+			modify: func(input *SoSwapViaStargateInputData) {
+				input.StargateData.DstStargatePoolId = big.NewInt(13)
+			},
+			want: []string{"chain-path"},
+		},
+		{
+			name: "unknown router and zero receiver",
+			modify: func(input *SoSwapViaStargateInputData) {
+				input.SoData.Receiver = common.Address{}
+				input.SwapDataSrc = []SwapData{{CallTo: common.HexToAddress("0x2222222222222222222222222222222222222222")}}
+			},
+			want: []string{"swap-router", "receiver"},
+		},
+		{
+			name: "valid src swap",
+			modify: func(input *SoSwapViaStargateInputData) {
+				input.SwapDataSrc = []SwapData{v2Swap(990000, now.Unix()+600)}
+			},
+		},
+		{
+			name: "zero minimums and past deadline",
+			modify: func(input *SoSwapViaStargateInputData) {
+				input.SwapDataSrc = []SwapData{v2Swap(0, now.Unix()-1)}
+				input.StargateData.MinAmount = big.NewInt(0)
+			},
+			want: []string{"min-amount", "min-amount", "deadline"},
+		},
+		{
+			name: "dst gas",
+			modify: func(input *SoSwapViaStargateInputData) {
+				input.StargateData.DstGasForSgReceive = big.NewInt(0)
+			},
+			want: []string{"dst-gas"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := valid()
+			tt.modify(input)
+			findings := lintSoSwapViaStargate(soCall{chain: chain, time: now}, input)
+			rules := make([]string, 0)
+			for _, f := range findings {
+				rules = append(rules, f.Rule)
+			}
+			if len(rules) != len(tt.want) {
+				t.Fatalf("lintSoSwapViaStargate() = %v, want rules %v", findings, tt.want)
+			}
+			for i := range rules {
+				if rules[i] != tt.want[i] {
+					t.Errorf("lintSoSwapViaStargate() = %v, want rules %v", findings, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"context"
+
+	"github.com/xiang-xx/oparse/xabi"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// multicall3Address Multicall3 在所有支持的链上部署地址相同
+// https://github.com/mds1/multicall
+var multicall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+type call3 struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+type call3Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// multicall 通过 Multicall3.aggregate3 一次 eth_call 执行多个只读调用，单个调用失败不影响其他调用
+func multicall(ctx context.Context, client *ethclient.Client, calls []call3) ([]call3Result, error) {
+	input, err := xabi.Multicall3.Pack("aggregate3", calls)
+	if err != nil {
+		return nil, err
+	}
+	output, err := client.CallContract(ctx, ethereum.CallMsg{
+		To:   &multicall3Address,
+		Data: input,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	method := xabi.Multicall3.Methods["aggregate3"]
+	values, err := method.Outputs.Unpack(output)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]call3Result, 0, len(calls))
+	err = method.Outputs.Copy(&results, values)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/xiang-xx/oparse/config"
+	"github.com/xiang-xx/oparse/xabi"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/fatih/color"
+)
+
+// quoteSwap 在 block 上（nil 表示 latest）查询 swap 的预计输出，V2 使用 router 的 getAmountsOut，V3 使用配置的 Quoter
+func (d *Decoder) quoteSwap(ctx context.Context, chain *config.ChainInfo, swap *swapCall, amountIn *big.Int, block *big.Int) (*big.Int, error) {
+	var quoteAbi *abi.ABI
+	var to common.Address
+	var input []byte
+	var err error
+	switch swap.Router.Type {
+	case "ISwapRouter":
+		if swap.Router.Quoter == "" {
+			return nil, errors.New("no Uniswap V3 quoter configured for " + swap.Router.Name)
+		}
+		path, err := encodePath(swap.Path, swap.Fees)
+		if err != nil {
+			return nil, err
+		}
+		quoteAbi, to = &xabi.IQuoter, common.HexToAddress(swap.Router.Quoter)
+		input, err = quoteAbi.Pack("quoteExactInput", path, amountIn)
+		if err != nil {
+			return nil, err
+		}
+	case "IUniswapV2Router02AVAX":
+		quoteAbi, to = &xabi.IUniswapV2Router02AVAX, common.HexToAddress(swap.Router.RouterAddress)
+		input, err = quoteAbi.Pack("getAmountsOut", amountIn, swap.Path)
+	default:
+		quoteAbi, to = &xabi.IUniswapV2Router02, common.HexToAddress(swap.Router.RouterAddress)
+		input, err = quoteAbi.Pack("getAmountsOut", amountIn, swap.Path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := d.client(chain)
+	if err != nil {
+		return nil, err
+	}
+	callCtx, cancel := d.callContext(ctx)
+	defer cancel()
+	output, err := client.CallContract(callCtx, ethereum.CallMsg{To: &to, Data: input}, block)
+	if err != nil {
+		if reason, ok := revertReason(err); ok {
+			return nil, errors.New("reverted: " + reason)
+		}
+		return nil, err
+	}
+
+	if swap.Router.Type == "ISwapRouter" {
+		values, err := quoteAbi.Unpack("quoteExactInput", output)
+		if err != nil {
+			return nil, err
+		}
+		return values[0].(*big.Int), nil
+	}
+	values, err := quoteAbi.Unpack("getAmountsOut", output)
+	if err != nil {
+		return nil, err
+	}
+	amounts := values[0].([]*big.Int)
+	if len(amounts) == 0 {
+		return nil, errors.New("empty getAmountsOut result")
+	}
+	return amounts[len(amounts)-1], nil
+}
+
+// printQuote 输出 swap 在 block 上的预计输出，与 AmountOutMin 对比：预计输出低于最小数量时交易失败是最小数量设置不合理，
+// 预计输出足够但交易失败则是价格在交易前变化
+func (d *Decoder) printQuote(ctx context.Context, w io.Writer, chain *config.ChainInfo, block *big.Int, swap *swapCall, swapItem SwapData, token Token) {
+	amountIn := swap.AmountIn
+	if nil == amountIn {
+		amountIn = swapItem.FromAmount
+	}
+	at := "latest block"
+	if block != nil {
+		at = "block " + block.String()
+	}
+	expected, err := d.quoteSwap(ctx, chain, swap, amountIn, block)
+	if err != nil {
+		printAlignLine(w, "", color.HiYellowString("warning: quote at %s failed: %s", at, err))
+		return
+	}
+	content := "Expected      " + d.formatToken(expected, token) + " at " + at
+	margin, percent := slippageMargin(expected, swap.AmountOutMin)
+	if margin.Sign() < 0 {
+		printAlignLine(w, "", color.HiRedString("%s, below AmountOutMin", content))
+		return
+	}
+	printAlignLine(w, "", fmt.Sprintf("%s, AmountOutMin is %s%% below", content, percent))
+}
+
+// printQuotes 输出未签名 calldata 中每个 swap 的预计输出
+func (d *Decoder) printQuotes(ctx context.Context, w io.Writer, where string, chain *config.ChainInfo, block *big.Int, swapData []SwapData) {
+	for i, swapItem := range swapData {
+		router, ok := findRouter(chain, swapItem.CallTo)
+		if !ok {
+			continue
+		}
+		swap, err := decodeSwapCall(router, swapItem)
+		if err != nil || nil == swap {
+			continue
+		}
+		token, err := d.getTokenInfo(ctx, chain, swapItem.ReceivingAssetId)
+		if err != nil {
+			token = Token{}
+		}
+		printAlignLine(w, "Quote", fmt.Sprintf("%s[%d] %s", where, i, router.Name))
+		d.printQuote(ctx, w, chain, block, swap, swapItem, token)
+	}
+}
@@ -0,0 +1,154 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/xiang-xx/oparse/config"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/fatih/color"
+)
+
+// reconcileStargate 核对 soSwapViaStargate 中 tx.Value、SoData.Amount、源链 swap、Stargate pool 与目标链 swap 之间的数量和资产关系
+func (d *Decoder) reconcileStargate(ctx context.Context, fromChain, toChain *config.ChainInfo, value *big.Int, input *SoSwapViaStargateInputData) []string {
+	soData := input.SoData
+	warnings := make([]string, 0)
+	native := nativeToken(fromChain)
+	if isZeroAddress(soData.SendingAssetId) {
+		// 发送原生币时 tx.Value = Amount + Stargate/LayerZero 手续费
+		switch value.Cmp(soData.Amount) {
+		case -1:
+			warnings = append(warnings, fmt.Sprintf("tx.Value %s is less than SoData.Amount %s", d.formatToken(value, native), d.formatToken(soData.Amount, native)))
+		case 0:
+			warnings = append(warnings, fmt.Sprintf("tx.Value equals SoData.Amount %s, nothing left for the Stargate fee", d.formatToken(soData.Amount, native)))
+		}
+	} else if value.Sign() == 0 {
+		warnings = append(warnings, "tx.Value is 0, but the Stargate fee must be paid in "+native.Symbol)
+	}
+
+	warnings = append(warnings, d.reconcileSwaps(ctx, "SrcSwap", fromChain, soData.SendingAssetId, soData.Amount, input.SwapDataSrc)...)
+
+	// 源链 swap 的输出（没有 swap 时为发送的资产）进入 Stargate 源 pool
+	bridged := soData.SendingAssetId
+	if len(input.SwapDataSrc) > 0 {
+		bridged = input.SwapDataSrc[len(input.SwapDataSrc)-1].ReceivingAssetId
+	}
+	srcPool := config.GetStargatePool(fromChain, int(input.StargateData.SrcStargatePoolId.Int64()))
+	if nil == srcPool {
+		warnings = append(warnings, fmt.Sprintf("src stargate pool %s not found on %s", input.StargateData.SrcStargatePoolId, fromChain.ChainName))
+	} else if !poolAcceptsAsset(fromChain, srcPool, bridged) {
+		warnings = append(warnings, fmt.Sprintf("asset %s sent to Stargate is not src pool %s token %s", bridged, srcPool.TokenName, srcPool.TokenAddress))
+	} else if len(input.SwapDataSrc) == 0 {
+		// 没有源链 swap 时，Stargate 最少到账数量不应大于发送数量
+		if input.StargateData.MinAmount.Cmp(soData.Amount) > 0 {
+			poolToken := Token{Symbol: srcPool.TokenName, Decimals: srcPool.Decimal}
+			warnings = append(warnings, fmt.Sprintf("Stargate MinAmount %s is greater than SoData.Amount %s", d.formatToken(input.StargateData.MinAmount, poolToken), d.formatToken(soData.Amount, poolToken)))
+		}
+	}
+
+	// Stargate 目标 pool 的 token 进入目标链 swap（没有 swap 时直接发给 Receiver）
+	dstPool := config.GetStargatePool(toChain, int(input.StargateData.DstStargatePoolId.Int64()))
+	if nil == dstPool {
+		warnings = append(warnings, fmt.Sprintf("dst stargate pool %s not found on %s", input.StargateData.DstStargatePoolId, toChain.ChainName))
+		return warnings
+	}
+	if len(input.SwapDataDst) == 0 {
+		if !poolAcceptsAsset(toChain, dstPool, soData.ReceivingAssetId) {
+			warnings = append(warnings, fmt.Sprintf("ReceivingAssetId %s is not dst pool %s token %s and there is no DstSwap", soData.ReceivingAssetId, dstPool.TokenName, dstPool.TokenAddress))
+		}
+		return warnings
+	}
+	if !poolAcceptsAsset(toChain, dstPool, input.SwapDataDst[0].SendingAssetId) {
+		warnings = append(warnings, fmt.Sprintf("DstSwap starts with %s, but dst pool %s token is %s", input.SwapDataDst[0].SendingAssetId, dstPool.TokenName, dstPool.TokenAddress))
+	}
+	warnings = append(warnings, reconcileSwapAssets("DstSwap", toChain, input.SwapDataDst)...)
+	last := input.SwapDataDst[len(input.SwapDataDst)-1]
+	if !sameAsset(toChain, last.ReceivingAssetId, soData.ReceivingAssetId) {
+		warnings = append(warnings, fmt.Sprintf("DstSwap ends with %s, but ReceivingAssetId is %s", last.ReceivingAssetId, soData.ReceivingAssetId))
+	}
+	return warnings
+}
+
+// reconcileGeneric 核对 swapTokensGeneric 中 tx.Value、SoData.Amount 与 swap 的关系
+func (d *Decoder) reconcileGeneric(ctx context.Context, chain *config.ChainInfo, value *big.Int, input *GenericInputData) []string {
+	soData := input.SoData
+	warnings := make([]string, 0)
+	native := nativeToken(chain)
+	if isZeroAddress(soData.SendingAssetId) {
+		if value.Cmp(soData.Amount) != 0 {
+			warnings = append(warnings, fmt.Sprintf("tx.Value %s differs from SoData.Amount %s", d.formatToken(value, native), d.formatToken(soData.Amount, native)))
+		}
+	} else if value.Sign() != 0 {
+		warnings = append(warnings, fmt.Sprintf("tx.Value %s is not 0 for an ERC20 swap", d.formatToken(value, native)))
+	}
+	warnings = append(warnings, d.reconcileSwaps(ctx, "SrcSwap", chain, soData.SendingAssetId, soData.Amount, input.SwapData)...)
+	if len(input.SwapData) > 0 {
+		last := input.SwapData[len(input.SwapData)-1]
+		if !sameAsset(chain, last.ReceivingAssetId, soData.ReceivingAssetId) {
+			warnings = append(warnings, fmt.Sprintf("SrcSwap ends with %s, but ReceivingAssetId is %s", last.ReceivingAssetId, soData.ReceivingAssetId))
+		}
+	}
+	return warnings
+}
+
+// reconcileSwaps 核对第一个 swap 的输入资产和数量与 SoData 一致，以及 swap 之间资产首尾相接
+func (d *Decoder) reconcileSwaps(ctx context.Context, where string, chain *config.ChainInfo, sendingAsset common.Address, amount *big.Int, swapData []SwapData) []string {
+	warnings := make([]string, 0)
+	if len(swapData) == 0 {
+		return warnings
+	}
+	first := swapData[0]
+	if !sameAsset(chain, first.SendingAssetId, sendingAsset) {
+		warnings = append(warnings, fmt.Sprintf("%s[0].SendingAssetId %s differs from SoData.SendingAssetId %s", where, first.SendingAssetId, sendingAsset))
+	}
+	if first.FromAmount.Cmp(amount) != 0 {
+		token, err := d.getTokenInfo(ctx, chain, sendingAsset)
+		if err != nil {
+			token = Token{}
+		}
+		diff := new(big.Int).Sub(amount, first.FromAmount)
+		warnings = append(warnings, fmt.Sprintf("%s[0].FromAmount %s differs from SoData.Amount %s by %s", where, d.formatToken(first.FromAmount, token), d.formatToken(amount, token), d.formatToken(diff, token)))
+	}
+	return append(warnings, reconcileSwapAssets(where, chain, swapData)...)
+}
+
+// reconcileSwapAssets 检查前一个 swap 的输出资产是后一个 swap 的输入资产
+func reconcileSwapAssets(where string, chain *config.ChainInfo, swapData []SwapData) []string {
+	warnings := make([]string, 0)
+	for i := 1; i < len(swapData); i++ {
+		if !sameAsset(chain, swapData[i-1].ReceivingAssetId, swapData[i].SendingAssetId) {
+			warnings = append(warnings, fmt.Sprintf("%s[%d] receives %s, but %s[%d] sends %s", where, i-1, swapData[i-1].ReceivingAssetId, where, i, swapData[i].SendingAssetId))
+		}
+	}
+	return warnings
+}
+
+// sameAsset 原生币（0 地址）与 wrapped native token 由 SoDiamond 自动转换，视为同一资产
+func sameAsset(chain *config.ChainInfo, a, b common.Address) bool {
+	return swapPathAsset(chain, a) == swapPathAsset(chain, b)
+}
+
+// poolAcceptsAsset 资产是否为 Stargate pool 的 token，SGETH pool 接受原生币
+func poolAcceptsAsset(chain *config.ChainInfo, pool *config.Pool, asset common.Address) bool {
+	if pool.TokenName == "SGETH" && (isZeroAddress(asset) || isWrappedNative(chain, asset)) {
+		return true
+	}
+	return asset == common.HexToAddress(pool.TokenAddress)
+}
+
+// printWarnings 输出检查结果，没有警告时输出 ok
+func (d *Decoder) printWarnings(w io.Writer, where string, warnings []string) {
+	if len(warnings) == 0 {
+		printAlignLine(w, where, "ok")
+		return
+	}
+	for i, warning := range warnings {
+		if i > 0 {
+			where = ""
+		}
+		printAlignLine(w, where, color.HiYellowString("warning: %s", warning))
+	}
+}
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/xiang-xx/oparse/config"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func Test_reconcileSwapAssets(t *testing.T) {
+	usdc := common.HexToAddress("0xff970a61a04b1ca14834a43f5de4533ebddb5cc8")
+	usdt := common.HexToAddress("0xfd086bc7cd5c481dcc9c85ebe478a1c0b69fcbb9")
+	weth := common.HexToAddress("0x82af49447d8a07e3bd95bd0d56f35241523fbab1")
+	chain := &config.ChainInfo{WETH: weth.Hex()}
+	tests := []struct {
+		name     string
+		swapData []SwapData
+		want     int
+	}{
+		{
+			name: "continuous",
+			swapData: []SwapData{
+				{SendingAssetId: usdc, ReceivingAssetId: usdt},
+				{SendingAssetId: usdt, ReceivingAssetId: weth},
+			},
+		},
+		{
+			name: "native and wrapped native",
+			swapData: []SwapData{
+				{SendingAssetId: usdc, ReceivingAssetId: weth},
+				{ReceivingAssetId: usdt},
+			},
+		},
+		{
+			name: "broken",
+			swapData: []SwapData{
+				{SendingAssetId: usdc, ReceivingAssetId: usdt},
+				{SendingAssetId: usdc, ReceivingAssetId: weth},
+			},
+			want: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reconcileSwapAssets("SrcSwap", chain, tt.swapData); len(got) != tt.want {
+				t.Errorf("reconcileSwapAssets() = %v, want %d warnings", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/xiang-xx/oparse/xabi"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	errorStringSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+	panicSelector       = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+)
+
+// decodeRevert 解析 revert 数据，支持 Error(string)、Panic(uint256) 和 SoDiamond 自定义错误
+func decodeRevert(data []byte) string {
+	if len(data) == 0 {
+		return "reverted without reason"
+	}
+	if len(data) < 4 {
+		return "unknown revert data " + hexutil.Encode(data)
+	}
+	selector := data[:4]
+	switch {
+	case bytes.Equal(selector, errorStringSelector):
+		reason, err := abi.UnpackRevert(data)
+		if err != nil {
+			break
+		}
+		return reason
+	case bytes.Equal(selector, panicSelector):
+		if len(data) == 36 {
+			return "panic " + hexutil.EncodeBig(new(big.Int).SetBytes(data[4:]))
+		}
+	}
+	for _, e := range xabi.SoDiamond.Errors {
+		if !bytes.Equal(e.ID[:4], selector) {
+			continue
+		}
+		values, err := e.Inputs.Unpack(data[4:])
+		if err != nil {
+			break
+		}
+		args := make([]string, 0, len(values))
+		for _, v := range values {
+			args = append(args, fmt.Sprint(v))
+		}
+		return e.Name + "(" + strings.Join(args, ", ") + ")"
+	}
+	return "unknown revert data " + hexutil.Encode(data)
+}
+
+// revertCode 节点对 revert 返回的 JSON-RPC 错误码
+const revertCode = 3
+
+// revertReason 从 eth_call、eth_estimateGas 的错误中解析 revert 原因。
+// go-ethereum 的所有 JSON-RPC 错误都实现了 rpc.DataError，只有带 revert 数据、错误码为 3 或 message 以 execution reverted 开头的错误才是 revert，
+// 其他错误（header not found、missing trie node、限流、insufficient funds 等）返回 false，由调用方作为 rpc 错误处理
+func revertReason(err error) (string, bool) {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) && dataErr.ErrorData() != nil {
+		if data, ok := dataErr.ErrorData().(string); ok {
+			if revertData, decodeErr := hexutil.Decode(data); decodeErr == nil {
+				return decodeRevert(revertData), true
+			}
+		}
+		return err.Error(), true
+	}
+	var rpcErr rpc.Error
+	if (errors.As(err, &rpcErr) && rpcErr.ErrorCode() == revertCode) || strings.HasPrefix(err.Error(), "execution reverted") {
+		if reason := strings.TrimPrefix(strings.TrimPrefix(err.Error(), "execution reverted"), ": "); reason != "" {
+			return reason, true
+		}
+		return decodeRevert(nil), true
+	}
+	return "", false
+}
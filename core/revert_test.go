@@ -0,0 +1,67 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/xiang-xx/oparse/xabi"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func Test_decodeRevert(t *testing.T) {
+	errorString, _ := hexutil.Decode("0x08c379a00000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000000c696e76616c696420706174680000000000000000000000000000000000000000")
+	panicData, _ := hexutil.Decode("0x4e487b710000000000000000000000000000000000000000000000000000000000000011")
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{name: "empty", data: nil, want: "reverted without reason"},
+		{name: "error string", data: errorString, want: "invalid path"},
+		{name: "panic", data: panicData, want: "panic 0x11"},
+		{name: "custom error", data: xabi.SoDiamond.Errors["NoSwapDataProvided"].ID.Bytes()[:4], want: "NoSwapDataProvided()"},
+		{name: "unknown", data: []byte{1, 2, 3, 4}, want: "unknown revert data 0x01020304"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeRevert(tt.data); got != tt.want {
+				t.Errorf("decodeRevert() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// jsonRPCError 模拟节点返回的 JSON-RPC 错误
+type jsonRPCError struct {
+	code    int
+	message string
+	data    interface{}
+}
+
+func (e jsonRPCError) Error() string          { return e.message }
+func (e jsonRPCError) ErrorCode() int         { return e.code }
+func (e jsonRPCError) ErrorData() interface{} { return e.data }
+
+func Test_revertReason(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		want     string
+		isRevert bool
+	}{
+		{name: "revert data", err: jsonRPCError{code: 3, message: "execution reverted", data: "0x01020304"}, want: "unknown revert data 0x01020304", isRevert: true},
+		{name: "reason in message", err: jsonRPCError{code: -32000, message: "execution reverted: UniswapV2: EXPIRED"}, want: "UniswapV2: EXPIRED", isRevert: true},
+		{name: "code 3 without data", err: jsonRPCError{code: 3, message: "execution reverted"}, want: "reverted without reason", isRevert: true},
+		{name: "header not found", err: jsonRPCError{code: -32000, message: "header not found"}},
+		{name: "rate limit", err: jsonRPCError{code: 429, message: "too many requests"}},
+		{name: "insufficient funds", err: jsonRPCError{code: -32000, message: "insufficient funds for gas * price + value"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := revertReason(tt.err)
+			if ok != tt.isRevert || got != tt.want {
+				t.Errorf("revertReason() = %q, %v, want %q, %v", got, ok, tt.want, tt.isRevert)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/xiang-xx/oparse/config"
+)
+
+// StargateRoute 两条链之间 Stargate 支持的一条 pool 路径
+type StargateRoute struct {
+	SrcPool config.Pool
+	DstPool config.Pool
+}
+
+// StargateRoutes 按源 pool 的 ChainPath 列出 fromChain 到 toChain 的所有 pool 路径
+func StargateRoutes(fromChain, toChain *config.ChainInfo) []StargateRoute {
+	routes := make([]StargateRoute, 0)
+	for _, srcPool := range fromChain.StargatePool {
+		for _, dstPool := range toChain.StargatePool {
+			if srcPool.SupportsPath(toChain.StargateChainId, dstPool.PoolId) {
+				routes = append(routes, StargateRoute{SrcPool: srcPool, DstPool: dstPool})
+			}
+		}
+	}
+	return routes
+}
+
+// PrintStargateRoutes 输出 fromChain 到 toChain 的所有 pool 路径
+func PrintStargateRoutes(w io.Writer, fromChain, toChain *config.ChainInfo) {
+	printAlignLine(w, "From", fmt.Sprintf("%s(stargate chain %d)", fromChain.ChainName, fromChain.StargateChainId))
+	printAlignLine(w, "To", fmt.Sprintf("%s(stargate chain %d)", toChain.ChainName, toChain.StargateChainId))
+	routes := StargateRoutes(fromChain, toChain)
+	if len(routes) == 0 {
+		printAlignLine(w, "Routes", "no Stargate pool route")
+		return
+	}
+	for i, route := range routes {
+		where := ""
+		if i == 0 {
+			where = "Routes"
+		}
+		printAlignLine(w, where, fmt.Sprintf("%s(%d) -> %s(%d)", route.SrcPool.TokenName, route.SrcPool.PoolId, route.DstPool.TokenName, route.DstPool.PoolId))
+	}
+}
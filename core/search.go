@@ -0,0 +1,124 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/xiang-xx/oparse/config"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fatih/color"
+)
+
+// ChainResult 单条链上的查询结果
+type ChainResult struct {
+	Chain   string
+	Found   bool
+	Receipt *types.Receipt // 交易未打包或获取失败时为 nil
+	Err     error          // rpc 错误，查到交易时为获取 receipt 的错误
+}
+
+// Reverted 交易已打包且执行失败
+func (r ChainResult) Reverted() bool {
+	return r.Receipt != nil && r.Receipt.Status == types.ReceiptStatusFailed
+}
+
+// SearchResult 多条链上的查询结果，按链名排序
+type SearchResult struct {
+	Results []ChainResult
+}
+
+func (s SearchResult) Found() []ChainResult {
+	found := make([]ChainResult, 0)
+	for _, r := range s.Results {
+		if r.Found {
+			found = append(found, r)
+		}
+	}
+	return found
+}
+
+func (s SearchResult) Errors() []ChainResult {
+	errs := make([]ChainResult, 0)
+	for _, r := range s.Results {
+		if r.Err != nil {
+			errs = append(errs, r)
+		}
+	}
+	return errs
+}
+
+// SearchTx 在多条链上并发查询交易，等待所有链上的查询完成，不因某条链先查到而取消其他链，
+// 同一 hash 出现在多条链上时都会输出，结果不受 rpc 响应快慢影响，单条链最长等待 rpc-timeout
+// 每条链的交易信息先写入各自的 buffer，全部完成后按链名顺序输出到 w，避免多条链上的输出交错，dstTxHash 见 PrintTx
+func (d *Decoder) SearchTx(ctx context.Context, w io.Writer, chains []config.ChainInfo, txHash, dstTxHash string) SearchResult {
+	sort.Slice(chains, func(i, j int) bool {
+		return chains[i].ChainName < chains[j].ChainName
+	})
+	results := make([]ChainResult, len(chains))
+	outputs := make([]bytes.Buffer, len(chains))
+	wg := sync.WaitGroup{}
+	for i := range chains {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			chain := &chains[i]
+			results[i].Chain = chain.ChainName
+			tx, err := d.FindTx(ctx, chain, txHash)
+			if err != nil {
+				if !errors.Is(err, ethereum.NotFound) {
+					results[i].Err = err
+				}
+				return
+			}
+			results[i].Found = true
+			results[i].Receipt, results[i].Err = d.PrintTx(ctx, &outputs[i], chain, tx, dstTxHash)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range outputs {
+		outputs[i].WriteTo(w)
+	}
+	return SearchResult{Results: results}
+}
+
+// PrintSearchSummary 输出查询了哪些链、哪些 rpc 出错以及在哪条链上查到交易
+func PrintSearchSummary(w io.Writer, s SearchResult) {
+	searched := make([]string, 0, len(s.Results))
+	for _, r := range s.Results {
+		searched = append(searched, r.Chain)
+	}
+
+	printLine(w)
+	printAlignLine(w, "Searched", strings.Join(searched, ", "))
+	for _, r := range s.Errors() {
+		printError(w, "rpc "+r.Chain, r.Err)
+	}
+	found := s.Found()
+	if len(found) == 0 {
+		if len(s.Errors()) > 0 {
+			printAlignLine(w, "Found", color.HiRedString("not found on any reachable chain"))
+		} else {
+			printAlignLine(w, "Found", color.HiRedString("not found on any chain"))
+		}
+		return
+	}
+	for _, r := range found {
+		status := "success"
+		if r.Err != nil {
+			status = "receipt unavailable"
+		} else if r.Receipt == nil {
+			status = "pending"
+		} else if r.Reverted() {
+			status = "reverted"
+		}
+		printAlignLine(w, "Found", r.Chain+" ("+status+")")
+	}
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+
+	"github.com/xiang-xx/oparse/config"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/fatih/color"
+)
+
+// SimulateResult 模拟执行的结果
+type SimulateResult struct {
+	Reverted bool
+	Reason   string // revert 原因
+	Gas      uint64 // 预估 gas，revert 时为 0
+}
+
+// SimulateCalldata 在 block 上（nil 表示 latest）以 from 的身份用 eth_call 执行发往 SoDiamond 的 calldata，
+// 成功时再用 eth_estimateGas 预估 gas，revert 时解析 Error(string) 和 SoDiamond 自定义错误
+func (d *Decoder) SimulateCalldata(ctx context.Context, w io.Writer, chain *config.ChainInfo, from common.Address, value *big.Int, input []byte, block *big.Int) (SimulateResult, error) {
+	rpcClient, err := d.rpcClient(chain)
+	if err != nil {
+		return SimulateResult{}, err
+	}
+	blockTag, blockLabel := "latest", "latest block"
+	if block != nil {
+		blockTag, blockLabel = hexutil.EncodeBig(block), "block "+block.String()
+	}
+	to := common.HexToAddress(chain.SoDiamond)
+	arg := map[string]interface{}{
+		"from":  from,
+		"to":    to,
+		"data":  hexutil.Bytes(input),
+		"value": (*hexutil.Big)(value),
+	}
+
+	defer d.printCalldataQuotes(ctx, w, chain, block, input)
+
+	printAlignLine(w, "Simulate", fmt.Sprintf("from %s at %s via %s", from, blockLabel, chain.Rpc))
+	var output hexutil.Bytes
+	callCtx, cancel := d.callContext(ctx)
+	err = rpcClient.CallContext(callCtx, &output, "eth_call", arg, blockTag)
+	cancel()
+	if err != nil {
+		if reason, ok := revertReason(err); ok {
+			printAlignLine(w, "", color.HiRedString("reverted: %s", reason))
+			return SimulateResult{Reverted: true, Reason: reason}, nil
+		}
+		return SimulateResult{}, err
+	}
+	printAlignLine(w, "", color.HiGreenString("success"))
+
+	var gas hexutil.Uint64
+	callCtx, cancel = d.callContext(ctx)
+	err = rpcClient.CallContext(callCtx, &gas, "eth_estimateGas", arg, blockTag)
+	cancel()
+	if err != nil {
+		// 部分节点不支持在指定区块上预估 gas
+		printError(w, "eth_estimateGas", err)
+		return SimulateResult{}, nil
+	}
+	printAlignLine(w, "", alignString("Gas", 10)+strconv.FormatUint(uint64(gas), 10))
+	return SimulateResult{Gas: uint64(gas)}, nil
+}
+
+// printCalldataQuotes 输出 calldata 中源链 swap 在 block 上的预计输出，revert 时可以判断是否为最小数量设置不合理
+func (d *Decoder) printCalldataQuotes(ctx context.Context, w io.Writer, chain *config.ChainInfo, block *big.Int, input []byte) {
+	decoded, err := decodeSoDiamondCall(input)
+	if err != nil {
+		return
+	}
+	switch inputStructData := decoded.(type) {
+	case *SoSwapViaStargateInputData:
+		d.printQuotes(ctx, w, "SrcSwap", chain, block, inputStructData.SwapDataSrc)
+	case *GenericInputData:
+		d.printQuotes(ctx, w, "SrcSwap", chain, block, inputStructData.SwapData)
+	}
+}
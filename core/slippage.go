@@ -0,0 +1,251 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/xiang-xx/oparse/config"
+	"github.com/xiang-xx/oparse/xabi"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/fatih/color"
+	"github.com/shopspring/decimal"
+)
+
+var transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// assetSwappedEvent SoDiamond 每完成一个 swap 触发一次 AssetSwapped
+type assetSwappedEvent struct {
+	TransactionId [32]byte
+	Dex           common.Address
+	FromAssetId   common.Address
+	ToAssetId     common.Address
+	FromAmount    *big.Int
+	ToAmount      *big.Int
+	Timestamp     *big.Int
+}
+
+// swapOutputs 从 receipt 中获取每个 swap 实际得到的数量，无法确定时为 nil
+// 优先使用 SoDiamond 的 AssetSwapped 事件，按 FromAssetId/ToAssetId 与 SwapData 匹配，
+// 没有匹配的事件时使用 SoDiamond 收到 ReceivingAssetId 的 ERC20 Transfer 日志
+func swapOutputs(chain *config.ChainInfo, receipt *types.Receipt, transactionId [32]byte, swapData []SwapData) []*big.Int {
+	outputs := make([]*big.Int, len(swapData))
+	soDiamond := common.HexToAddress(chain.SoDiamond)
+	event := xabi.SoDiamond.Events["AssetSwapped"]
+	swapped := make([]assetSwappedEvent, 0)
+	for _, log := range receipt.Logs {
+		e := assetSwappedEvent{}
+		if log.Address != soDiamond || !unpackEvent(event, log, &e) || e.TransactionId != transactionId {
+			continue
+		}
+		swapped = append(swapped, e)
+	}
+
+	usedEvents := make(map[int]bool)
+	usedLogs := make(map[int]bool)
+	for j, swapItem := range swapData {
+		for k, e := range swapped {
+			if usedEvents[k] || !sameAsset(chain, e.FromAssetId, swapItem.SendingAssetId) || !sameAsset(chain, e.ToAssetId, swapItem.ReceivingAssetId) {
+				continue
+			}
+			usedEvents[k] = true
+			outputs[j] = e.ToAmount
+			break
+		}
+		if outputs[j] != nil || isZeroAddress(swapItem.ReceivingAssetId) {
+			continue
+		}
+		// 按顺序匹配 SoDiamond 收到的 Transfer，原生币的输出没有 Transfer 日志
+		token := swapPathAsset(chain, swapItem.ReceivingAssetId)
+		for k, log := range receipt.Logs {
+			if usedLogs[k] || log.Address != token || len(log.Topics) != 3 || log.Topics[0] != transferTopic {
+				continue
+			}
+			if common.BytesToAddress(log.Topics[2].Bytes()) != soDiamond {
+				continue
+			}
+			usedLogs[k] = true
+			outputs[j] = new(big.Int).SetBytes(log.Data)
+			break
+		}
+	}
+	return outputs
+}
+
+// slippageMargin 实际数量比最小数量多出的部分及其占实际数量的百分比
+func slippageMargin(actual, min *big.Int) (*big.Int, decimal.Decimal) {
+	margin := new(big.Int).Sub(actual, min)
+	if actual.Sign() == 0 {
+		return margin, decimal.Zero
+	}
+	percent := decimal.NewFromBigInt(margin, 2).Div(decimal.NewFromBigInt(actual, 0)).Round(2)
+	return margin, percent
+}
+
+// printSlippage 对比每个 swap 实际得到的数量与 AmountOutMin，以及 Stargate 在目标链到账的数量与 StargateData.MinAmount，
+// 只在交易执行成功时输出，目标链 swap 在目标链上执行，只在有目标链交易 dstReceipt 时输出
+func (d *Decoder) printSlippage(ctx context.Context, w io.Writer, call soCall, toChain *config.ChainInfo, soData SoData, swapDataSrc []SwapData, stargateData *StargateData, swapDataDst []SwapData, dstReceipt *types.Receipt) {
+	if nil == call.receipt || call.receipt.Status != types.ReceiptStatusSuccessful {
+		return
+	}
+	chain := call.chain
+	where := "Slippage"
+	printItem := func(name, verb string, actual, min *big.Int, token Token) {
+		margin, percent := slippageMargin(actual, min)
+		content := fmt.Sprintf("%s  %s %s, min %s, margin %s (%s%%)", alignString(name, 11), verb, d.formatToken(actual, token), d.formatToken(min, token), d.formatToken(margin, token), percent)
+		if margin.Sign() < 0 {
+			content = color.HiRedString(content)
+		}
+		printAlignLine(w, where, content)
+		where = ""
+	}
+	printSwaps := func(prefix string, swapChain *config.ChainInfo, receipt *types.Receipt, swapData []SwapData) {
+		outputs := swapOutputs(swapChain, receipt, soData.TransactionId, swapData)
+		for i, swapItem := range swapData {
+			name := fmt.Sprintf("%s[%d]", prefix, i)
+			router, ok := findRouter(swapChain, swapItem.CallTo)
+			if !ok {
+				continue
+			}
+			swap, err := decodeSwapCall(router, swapItem)
+			if err != nil || nil == swap {
+				continue
+			}
+			token, err := d.getTokenInfo(ctx, swapChain, swapItem.ReceivingAssetId)
+			if err != nil {
+				token = Token{}
+			}
+			if nil == outputs[i] {
+				printAlignLine(w, where, alignString(name, 11)+"  actual output not found in receipt logs")
+				where = ""
+				continue
+			}
+			printItem(name, "got", outputs[i], swap.AmountOutMin, token)
+		}
+	}
+
+	printSwaps("SrcSwap", chain, call.receipt, swapDataSrc)
+	if dstReceipt != nil && dstReceipt.Status == types.ReceiptStatusSuccessful {
+		printSwaps("DstSwap", toChain, dstReceipt, swapDataDst)
+	}
+
+	if nil == stargateData {
+		return
+	}
+	srcPool := config.GetStargatePool(chain, int(stargateData.SrcStargatePoolId.Int64()))
+	if nil == srcPool {
+		return
+	}
+	delivered, verb, err := d.stargateDelivered(ctx, call, toChain, srcPool, stargateData, dstReceipt)
+	if err != nil {
+		printAlignLine(w, where, alignString("Stargate", 11)+"  "+color.HiYellowString("warning: %s", err))
+		return
+	}
+	printItem("Stargate", verb, delivered, stargateData.MinAmount, Token{Symbol: srcPool.TokenName, Decimals: srcPool.Decimal})
+}
+
+// stargateSwapEvent 源链 Stargate pool 的 Swap 事件，数量单位为 pool 的 shared decimals
+// AmountSD 已扣除 eqFee、protocolFee、lpFee，目标链到账 AmountSD + EqReward，Stargate 也用这个数量校验 minAmount
+type stargateSwapEvent struct {
+	ChainId     uint16
+	DstPoolId   *big.Int
+	From        common.Address
+	AmountSD    *big.Int
+	EqReward    *big.Int
+	EqFee       *big.Int
+	ProtocolFee *big.Int
+	LpFee       *big.Int
+}
+
+// stargateSwap 查找源链 receipt 中目标链、目标 pool 与 StargateData 一致的 Stargate pool Swap 事件
+func stargateSwap(receipt *types.Receipt, stargateData *StargateData) (*types.Log, *stargateSwapEvent) {
+	event := xabi.IStargatePool.Events["Swap"]
+	for _, log := range receipt.Logs {
+		swap := stargateSwapEvent{}
+		if !unpackEvent(event, log, &swap) {
+			continue
+		}
+		if swap.ChainId == stargateData.DstStargateChainId && swap.DstPoolId.Cmp(stargateData.DstStargatePoolId) == 0 {
+			return log, &swap
+		}
+	}
+	return nil, nil
+}
+
+// dstPoolTransfer 目标链 receipt 中目标 pool token 转给 SoDiamond 的数量，找不到时为 nil
+func dstPoolTransfer(toChain *config.ChainInfo, receipt *types.Receipt, pool *config.Pool) *big.Int {
+	token := common.HexToAddress(pool.TokenAddress)
+	soDiamond := common.HexToAddress(toChain.SoDiamond)
+	for _, log := range receipt.Logs {
+		if log.Address != token || len(log.Topics) != 3 || log.Topics[0] != transferTopic {
+			continue
+		}
+		if common.BytesToAddress(log.Topics[2].Bytes()) == soDiamond {
+			return new(big.Int).SetBytes(log.Data)
+		}
+	}
+	return nil
+}
+
+// convertDecimals 将 from 位精度的数量换算为 to 位精度，降低精度时舍去多余部分
+func convertDecimals(amount *big.Int, from, to int) *big.Int {
+	if from == to {
+		return amount
+	}
+	if to > from {
+		return new(big.Int).Mul(amount, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(to-from)), nil))
+	}
+	return new(big.Int).Quo(amount, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(from-to)), nil))
+}
+
+// stargateDelivered Stargate 在目标链到账的数量，换算为源链 pool 的精度以便与 MinAmount 对比，返回数量及其说明
+// 有目标链交易时取目标链 pool token 转给 SoDiamond 的数量，否则取源链 pool Swap 事件的 AmountSD + EqReward 乘以 convertRate
+func (d *Decoder) stargateDelivered(ctx context.Context, call soCall, toChain *config.ChainInfo, srcPool *config.Pool, stargateData *StargateData, dstReceipt *types.Receipt) (*big.Int, string, error) {
+	if dstReceipt != nil {
+		dstPool := config.GetStargatePool(toChain, int(stargateData.DstStargatePoolId.Int64()))
+		if dstPool != nil {
+			if received := dstPoolTransfer(toChain, dstReceipt, dstPool); received != nil {
+				return convertDecimals(received, dstPool.Decimal, srcPool.Decimal), "received on dst", nil
+			}
+		}
+	}
+	log, swap := stargateSwap(call.receipt, stargateData)
+	if nil == log {
+		return nil, "", errors.New("Stargate pool Swap event not found in receipt logs")
+	}
+	rate, err := d.stargateConvertRate(ctx, call.chain, log.Address, call.receipt.BlockNumber)
+	if err != nil {
+		return nil, "", err
+	}
+	delivered := new(big.Int).Add(swap.AmountSD, swap.EqReward)
+	return delivered.Mul(delivered, rate), "delivered", nil
+}
+
+// stargateConvertRate 查询 Stargate pool 的 convertRate，即 10^(local decimals - shared decimals)
+func (d *Decoder) stargateConvertRate(ctx context.Context, chain *config.ChainInfo, pool common.Address, block *big.Int) (*big.Int, error) {
+	input, err := xabi.IStargatePool.Pack("convertRate")
+	if err != nil {
+		return nil, err
+	}
+	client, err := d.client(chain)
+	if err != nil {
+		return nil, err
+	}
+	callCtx, cancel := d.callContext(ctx)
+	defer cancel()
+	output, err := client.CallContract(callCtx, ethereum.CallMsg{To: &pool, Data: input}, block)
+	if err != nil {
+		return nil, fmt.Errorf("convertRate of Stargate pool %s: %w", pool.Hex(), err)
+	}
+	values, err := xabi.IStargatePool.Unpack("convertRate", output)
+	if err != nil {
+		return nil, err
+	}
+	return values[0].(*big.Int), nil
+}
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/xiang-xx/oparse/config"
+	"github.com/xiang-xx/oparse/xabi"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func Test_swapOutputs(t *testing.T) {
+	soDiamond := common.HexToAddress("0x2967E7Bb9DaA5711Ac332cAF874BD47ef99B3820")
+	usdc := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	usdt := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	chain := &config.ChainInfo{SoDiamond: soDiamond.Hex()}
+	transactionId := [32]byte{1}
+	swapData := []SwapData{{SendingAssetId: usdt, ReceivingAssetId: usdc}}
+
+	event := xabi.SoDiamond.Events["AssetSwapped"]
+	data, err := event.Inputs.Pack(transactionId, common.Address{}, usdt, usdc, big.NewInt(100), big.NewInt(99), big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assetSwapped := &types.Log{Address: soDiamond, Topics: []common.Hash{event.ID}, Data: data}
+	transfer := &types.Log{
+		Address: usdc,
+		Topics:  []common.Hash{transferTopic, common.BytesToHash(usdt.Bytes()), common.BytesToHash(soDiamond.Bytes())},
+		Data:    common.BigToHash(big.NewInt(98)).Bytes(),
+	}
+	tests := []struct {
+		name string
+		logs []*types.Log
+		want *big.Int
+	}{
+		{name: "asset swapped", logs: []*types.Log{transfer, assetSwapped}, want: big.NewInt(99)},
+		{name: "transfer", logs: []*types.Log{transfer}, want: big.NewInt(98)},
+		{name: "not found", logs: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := swapOutputs(chain, &types.Receipt{Logs: tt.logs}, transactionId, swapData)[0]
+			if (got == nil) != (tt.want == nil) || (got != nil && got.Cmp(tt.want) != 0) {
+				t.Errorf("swapOutputs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_swapOutputs_matchByAsset(t *testing.T) {
+	soDiamond := common.HexToAddress("0x2967E7Bb9DaA5711Ac332cAF874BD47ef99B3820")
+	usdc := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	usdt := common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	dai := common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	chain := &config.ChainInfo{SoDiamond: soDiamond.Hex()}
+	transactionId := [32]byte{1}
+	// 第一个 swap 没有 AssetSwapped 事件，第二个 swap 的事件不能被当作第一个 swap 的输出
+	swapData := []SwapData{
+		{SendingAssetId: usdt, ReceivingAssetId: usdc},
+		{SendingAssetId: usdc, ReceivingAssetId: dai},
+	}
+	event := xabi.SoDiamond.Events["AssetSwapped"]
+	data, err := event.Inputs.Pack(transactionId, common.Address{}, usdc, dai, big.NewInt(98), big.NewInt(97), big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs := []*types.Log{
+		{
+			Address: usdc,
+			Topics:  []common.Hash{transferTopic, common.BytesToHash(usdt.Bytes()), common.BytesToHash(soDiamond.Bytes())},
+			Data:    common.BigToHash(big.NewInt(98)).Bytes(),
+		},
+		{Address: soDiamond, Topics: []common.Hash{event.ID}, Data: data},
+	}
+	got := swapOutputs(chain, &types.Receipt{Logs: logs}, transactionId, swapData)
+	if got[0] == nil || got[0].Int64() != 98 || got[1] == nil || got[1].Int64() != 97 {
+		t.Errorf("swapOutputs() = %v, want [98 97]", got)
+	}
+}
+
+func Test_slippageMargin(t *testing.T) {
+	margin, percent := slippageMargin(big.NewInt(1000), big.NewInt(995))
+	if margin.Int64() != 5 || percent.String() != "0.5" {
+		t.Errorf("slippageMargin() = %v, %v", margin, percent)
+	}
+}
+
+func Test_stargateSwap(t *testing.T) {
+	event := xabi.IStargatePool.Events["Swap"]
+	data, err := event.Inputs.Pack(uint16(109), big.NewInt(1), common.Address{}, big.NewInt(995), big.NewInt(2), big.NewInt(0), big.NewInt(3), big.NewInt(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	swapLog := &types.Log{Topics: []common.Hash{event.ID}, Data: data}
+	tests := []struct {
+		name      string
+		dstPoolId int64
+		want      *big.Int
+	}{
+		{name: "matched", dstPoolId: 1, want: big.NewInt(997)},
+		{name: "other pool", dstPoolId: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stargateData := &StargateData{DstStargateChainId: 109, DstStargatePoolId: big.NewInt(tt.dstPoolId)}
+			log, swap := stargateSwap(&types.Receipt{Logs: []*types.Log{swapLog}}, stargateData)
+			if (log == nil) != (tt.want == nil) {
+				t.Fatalf("stargateSwap() = %v, want %v", swap, tt.want)
+			}
+			if log != nil {
+				if got := new(big.Int).Add(swap.AmountSD, swap.EqReward); got.Cmp(tt.want) != 0 {
+					t.Errorf("AmountSD + EqReward = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func Test_convertDecimals(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		from, to int
+		want     int64
+	}{
+		{amount: 1234567, from: 6, to: 6, want: 1234567},
+		{amount: 1234567, from: 6, to: 8, want: 123456700},
+		{amount: 1234567, from: 6, to: 4, want: 12345},
+	}
+	for _, tt := range tests {
+		if got := convertDecimals(big.NewInt(tt.amount), tt.from, tt.to); got.Int64() != tt.want {
+			t.Errorf("convertDecimals(%d, %d, %d) = %v, want %d", tt.amount, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
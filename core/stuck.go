@@ -0,0 +1,254 @@
+package core
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/xiang-xx/oparse/config"
+	"github.com/xiang-xx/oparse/xabi"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/fatih/color"
+)
+
+// cachedSgReceiveEvent 目标链 SoDiamond sgReceive 失败时触发，字段与 Stargate 调用 sgReceive 的参数相同
+type cachedSgReceiveEvent struct {
+	ChainId    uint16
+	SrcAddress []byte
+	Nonce      *big.Int
+	Token      common.Address
+	Amount     *big.Int
+	Payload    []byte
+}
+
+// cachedSwapSavedEvent Stargate router 调用 sgReceive 失败、缓存 payload 时触发，reason 为 revert 数据
+type cachedSwapSavedEvent struct {
+	ChainId    uint16
+	SrcAddress []byte
+	Nonce      *big.Int
+	Token      common.Address
+	AmountLD   *big.Int
+	To         common.Address
+	Payload    []byte
+	Reason     []byte
+}
+
+// sgPayload soSwapViaStargate 发往目标链 sgReceive 的 payload
+type sgPayload struct {
+	SoData      SoData
+	SwapDataDst []SwapData
+}
+
+// decodeSgPayload payload = abi.encode(soData, swapDataDst)，与 sgReceiveForGas 的参数类型相同
+func decodeSgPayload(payload []byte) (*sgPayload, error) {
+	inputs := xabi.SoDiamond.Methods["sgReceiveForGas"].Inputs
+	args := abi.Arguments{inputs[0], inputs[2]}
+	values, err := args.Unpack(payload)
+	if err != nil {
+		return nil, err
+	}
+	out := &sgPayload{}
+	if err := args.Copy(out, values); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// unpackEvent 解析 log 中的非 indexed 字段，log 不是 event 时返回 false
+func unpackEvent(event abi.Event, log *types.Log, out interface{}) bool {
+	if len(log.Topics) == 0 || log.Topics[0] != event.ID {
+		return false
+	}
+	values, err := event.Inputs.Unpack(log.Data)
+	if err != nil {
+		return false
+	}
+	return event.Inputs.Copy(out, values) == nil
+}
+
+// cachedSwapKey Stargate router 缓存 payload 的 key，即 LayerZero 的源链 chainId、srcAddress（源链 bridge + 目标链 bridge）和 nonce
+type cachedSwapKey struct {
+	ChainId    uint16
+	SrcAddress []byte
+	Nonce      *big.Int
+}
+
+// cachedSwap Stargate router cachedSwapLookup 的返回值
+type cachedSwap struct {
+	Token    common.Address
+	AmountLD *big.Int
+	To       common.Address
+	Payload  []byte
+}
+
+// stargateSwapRemote Stargate bridge 发送 swap 消息时 payload 的第一个字段 TYPE_SWAP_REMOTE
+const stargateSwapRemote = 1
+
+// stargatePacketKey 从源链 receipt 中 LayerZero UltraLightNodeV2 的 Packet 事件推导目标链 Stargate router 缓存 payload 的 key，
+// Packet.payload = abi.encodePacked(nonce uint64, srcChainId uint16, srcBridge, dstChainId uint16, dstBridge, bridgePayload)，找不到时返回 nil
+func stargatePacketKey(toChain *config.ChainInfo, receipt *types.Receipt) *cachedSwapKey {
+	event := xabi.ILayerZeroUltraLightNodeV2.Events["Packet"]
+	for _, log := range receipt.Logs {
+		packet := struct{ Payload []byte }{}
+		if !unpackEvent(event, log, &packet) {
+			continue
+		}
+		p := packet.Payload
+		if len(p) < 84 || int(binary.BigEndian.Uint16(p[30:32])) != toChain.StargateChainId {
+			continue
+		}
+		if new(big.Int).SetBytes(p[52:84]).Cmp(big.NewInt(stargateSwapRemote)) != 0 {
+			continue
+		}
+		return &cachedSwapKey{
+			ChainId:    binary.BigEndian.Uint16(p[8:10]),
+			SrcAddress: append(append([]byte{}, p[10:30]...), p[32:52]...),
+			Nonce:      new(big.Int).SetUint64(binary.BigEndian.Uint64(p[0:8])),
+		}
+	}
+	return nil
+}
+
+// printStuckPayload 检查 payload 是否缓存在目标链 Stargate router 上，缓存时输出 payload、失败原因，
+// 以及在目标链 Stargate router 上重试所需的 clearCachedSwap 参数和 calldata
+// 有目标链交易且触发 CachedSgReceive 时以该事件为准，否则由源链 receipt 推导 key 查询 cachedSwapLookup，失败原因只能从目标链交易获取
+func (d *Decoder) printStuckPayload(ctx context.Context, w io.Writer, call soCall, toChain *config.ChainInfo, dstReceipt *types.Receipt) {
+	var key *cachedSwapKey
+	var sgReceive *cachedSgReceiveEvent
+	if dstReceipt != nil {
+		if log := findCachedSgReceive(toChain, dstReceipt); log != nil {
+			sgReceive = &cachedSgReceiveEvent{}
+			if !unpackEvent(xabi.SoDiamond.Events["CachedSgReceive"], log, sgReceive) {
+				printError(w, "Stuck", errors.New("decode CachedSgReceive failed"))
+				return
+			}
+			key = &cachedSwapKey{ChainId: sgReceive.ChainId, SrcAddress: sgReceive.SrcAddress, Nonce: sgReceive.Nonce}
+		}
+	}
+	if nil == key && call.receipt != nil && call.receipt.Status == types.ReceiptStatusSuccessful {
+		key = stargatePacketKey(toChain, call.receipt)
+	}
+	if nil == key {
+		return
+	}
+
+	router := common.HexToAddress(toChain.StargateRouter)
+	var cached *cachedSwap
+	var lookupErr error
+	if toChain.StargateRouter != "" {
+		cached, lookupErr = d.lookupCachedSwap(ctx, toChain, router, *key)
+	}
+	// 没有 CachedSgReceive 时只在 router 仍缓存 payload 或查询出错时输出
+	if nil == sgReceive && nil == cached {
+		if lookupErr != nil {
+			printError(w, "cachedSwapLookup", lookupErr)
+		}
+		return
+	}
+
+	printLine(w)
+	// 有 CachedSgReceive 时以事件为准，payload 已被重试清除、查询出错或未配置 router 时 cached 为 nil
+	var tokenAddress common.Address
+	var amount *big.Int
+	var payloadData []byte
+	if sgReceive != nil {
+		tokenAddress, amount, payloadData = sgReceive.Token, sgReceive.Amount, sgReceive.Payload
+	} else {
+		tokenAddress, amount, payloadData = cached.Token, cached.AmountLD, cached.Payload
+	}
+	token, err := d.getTokenInfo(ctx, toChain, tokenAddress)
+	if err != nil {
+		token = Token{}
+	}
+	printAlignLine(w, "Stuck", color.HiRedString("payload cached on %s", toChain.ChainName))
+	printAlignLine(w, "", alignString("Amount", 11)+d.formatToken(amount, token)+" "+tokenAddress.Hex())
+	if dstReceipt != nil {
+		printAlignLine(w, "", alignString("Reason", 11)+cachedSwapReason(toChain, dstReceipt, *key))
+	} else {
+		printAlignLine(w, "", alignString("Reason", 11)+"unknown, pass -dst-tx with the destination tx to get the failure reason")
+	}
+
+	payload, err := decodeSgPayload(payloadData)
+	if err != nil {
+		printError(w, "Payload", err)
+	} else {
+		d.printSoData(ctx, w, payload.SoData)
+		// 重试时才执行目标链 swap，deadline 以当前时间为准
+		d.printSwapData(ctx, w, "CachedSwap", toChain, swapEnv{deadline: deadlineRef{at: time.Now()}}, payload.SwapDataDst)
+	}
+
+	if toChain.StargateRouter == "" {
+		printAlignLine(w, "Retry", color.HiYellowString("warning: StargateRouter is not configured on %s", toChain.ChainName))
+		return
+	}
+	if lookupErr != nil {
+		printError(w, "cachedSwapLookup", lookupErr)
+	} else if nil == cached {
+		printAlignLine(w, "Retry", "already cleared, cachedSwapLookup is empty")
+		return
+	}
+	calldata, err := xabi.IStargateRouter.Pack("clearCachedSwap", key.ChainId, key.SrcAddress, key.Nonce)
+	if err != nil {
+		printError(w, "Retry", err)
+		return
+	}
+	printAlignLine(w, "Retry", fmt.Sprintf("clearCachedSwap on %s StargateRouter %s", toChain.ChainName, router.Hex()))
+	printAlignLine(w, "", alignString("SrcChainId", 11)+fmt.Sprint(key.ChainId))
+	printAlignLine(w, "", alignString("SrcAddress", 11)+hexutil.Encode(key.SrcAddress))
+	printAlignLine(w, "", alignString("Nonce", 11)+key.Nonce.String())
+	printAlignLine(w, "", alignString("Calldata", 11)+hexutil.Encode(calldata))
+}
+
+// cachedSwapReason 从目标链交易中 Stargate router 的 CachedSwapSaved 获取 sgReceive 的 revert 原因
+func cachedSwapReason(toChain *config.ChainInfo, receipt *types.Receipt, key cachedSwapKey) string {
+	router := common.HexToAddress(toChain.StargateRouter)
+	for _, log := range receipt.Logs {
+		saved := cachedSwapSavedEvent{}
+		if log.Address != router || !unpackEvent(xabi.IStargateRouter.Events["CachedSwapSaved"], log, &saved) {
+			continue
+		}
+		if saved.ChainId == key.ChainId && saved.Nonce.Cmp(key.Nonce) == 0 {
+			return decodeRevert(saved.Reason)
+		}
+	}
+	return "unknown, usually DstGasForSgReceive is too low or the dst swap failed"
+}
+
+// lookupCachedSwap 查询 Stargate router 的 cachedSwapLookup，没有缓存或已被重试清除（to 为 0 地址）时返回 nil
+func (d *Decoder) lookupCachedSwap(ctx context.Context, toChain *config.ChainInfo, router common.Address, key cachedSwapKey) (*cachedSwap, error) {
+	input, err := xabi.IStargateRouter.Pack("cachedSwapLookup", key.ChainId, key.SrcAddress, key.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	client, err := d.client(toChain)
+	if err != nil {
+		return nil, err
+	}
+	callCtx, cancel := d.callContext(ctx)
+	defer cancel()
+	output, err := client.CallContract(callCtx, ethereum.CallMsg{To: &router, Data: input}, nil)
+	if err != nil {
+		return nil, err
+	}
+	values, err := xabi.IStargateRouter.Unpack("cachedSwapLookup", output)
+	if err != nil {
+		return nil, err
+	}
+	cached := &cachedSwap{}
+	if err := xabi.IStargateRouter.Methods["cachedSwapLookup"].Outputs.Copy(cached, values); err != nil {
+		return nil, err
+	}
+	if isZeroAddress(cached.To) {
+		return nil, nil
+	}
+	return cached, nil
+}
@@ -0,0 +1,156 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xiang-xx/oparse/config"
+	"github.com/xiang-xx/oparse/xabi"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func Test_decodeSgPayload(t *testing.T) {
+	want := &sgPayload{
+		SoData: SoData{
+			TransactionId:      [32]byte{1},
+			Receiver:           common.HexToAddress("0x1111111111111111111111111111111111111111"),
+			SourceChainId:      big.NewInt(1),
+			SendingAssetId:     common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"),
+			DestinationChainId: big.NewInt(43114),
+			ReceivingAssetId:   common.Address{},
+			Amount:             big.NewInt(1000000),
+		},
+		SwapDataDst: []SwapData{{
+			CallTo:           common.HexToAddress("0x2222222222222222222222222222222222222222"),
+			ApproveTo:        common.HexToAddress("0x2222222222222222222222222222222222222222"),
+			SendingAssetId:   common.HexToAddress("0xB97EF9Ef8734C71904D8002F8b6Bc66Dd9c48a6E"),
+			ReceivingAssetId: common.Address{},
+			FromAmount:       big.NewInt(990000),
+			CallData:         []byte{1, 2, 3, 4},
+		}},
+	}
+	inputs := xabi.SoDiamond.Methods["sgReceiveForGas"].Inputs
+	payload, err := abi.Arguments{inputs[0], inputs[2]}.Pack(want.SoData, want.SwapDataDst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decodeSgPayload(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeSgPayload() = %+v, want %+v", got, want)
+	}
+
+	event := xabi.SoDiamond.Events["CachedSgReceive"]
+	data, err := event.Inputs.Pack(uint16(1), []byte{3}, big.NewInt(7), want.SoData.SendingAssetId, big.NewInt(1000000), payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached := cachedSgReceiveEvent{}
+	if !unpackEvent(event, &types.Log{Topics: []common.Hash{event.ID}, Data: data}, &cached) || cached.Nonce.Int64() != 7 {
+		t.Errorf("unpackEvent() = %+v", cached)
+	}
+}
+
+func Test_stargatePacketKey(t *testing.T) {
+	srcBridge := common.HexToAddress("0x296F55F8Fb28E498B858d0BcDA06D955B2Cb3f97")
+	dstBridge := common.HexToAddress("0x9d1B1669c73b033DFe47ae5a0164Ab96df25B944")
+	packet := func(dstChainId uint16, messageType int64) []byte {
+		p := []byte{0, 0, 0, 0, 0, 0, 0, 42, 0, 101}
+		p = append(p, srcBridge.Bytes()...)
+		p = append(p, byte(dstChainId>>8), byte(dstChainId))
+		p = append(p, dstBridge.Bytes()...)
+		return append(p, common.BigToHash(big.NewInt(messageType)).Bytes()...)
+	}
+	event := xabi.ILayerZeroUltraLightNodeV2.Events["Packet"]
+	toChain := &config.ChainInfo{StargateChainId: 106}
+	tests := []struct {
+		name    string
+		payload []byte
+		want    *cachedSwapKey
+	}{
+		{
+			name:    "swap remote",
+			payload: packet(106, stargateSwapRemote),
+			want:    &cachedSwapKey{ChainId: 101, SrcAddress: append(srcBridge.Bytes(), dstBridge.Bytes()...), Nonce: big.NewInt(42)},
+		},
+		{name: "other chain", payload: packet(102, stargateSwapRemote)},
+		{name: "not a swap", payload: packet(106, 2)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := event.Inputs.Pack(tt.payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			receipt := &types.Receipt{Logs: []*types.Log{{Topics: []common.Hash{event.ID}, Data: data}}}
+			if got := stargatePacketKey(toChain, receipt); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("stargatePacketKey() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_printStuckPayload(t *testing.T) {
+	// eth_call 都返回空的 cachedSwapLookup，即 payload 已被重试清除
+	empty, err := xabi.IStargateRouter.Methods["cachedSwapLookup"].Outputs.Pack(common.Address{}, big.NewInt(0), common.Address{}, []byte{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := struct {
+			Id json.RawMessage `json:"id"`
+		}{}
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.Id, "result": hexutil.Encode(empty)})
+	}))
+	defer server.Close()
+
+	soDiamond := common.HexToAddress("0x2967E7Bb9DaA5711Ac332cAF874BD47ef99B3820")
+	event := xabi.SoDiamond.Events["CachedSgReceive"]
+	data, err := event.Inputs.Pack(uint16(101), []byte{3}, big.NewInt(7), common.Address{}, big.NewInt(1000000), []byte{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dstReceipt := &types.Receipt{Logs: []*types.Log{{Address: soDiamond, Topics: []common.Hash{event.ID}, Data: data}}}
+	tests := []struct {
+		name    string
+		toChain *config.ChainInfo
+		want    string
+	}{
+		{
+			name:    "lookup empty",
+			toChain: &config.ChainInfo{ChainName: "stuck-test", Rpc: server.URL, SoDiamond: soDiamond.Hex(), StargateRouter: "0x45A01E4e04F14f7A4a6702c74187c5F6222033cd"},
+			want:    "already cleared",
+		},
+		{
+			name:    "router not configured",
+			toChain: &config.ChainInfo{ChainName: "stuck-test-no-router", SoDiamond: soDiamond.Hex()},
+			want:    "StargateRouter is not configured",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDecoder(Options{})
+			defer d.Close()
+			w := &bytes.Buffer{}
+			d.printStuckPayload(context.Background(), w, soCall{}, tt.toChain, dstReceipt)
+			if !strings.Contains(w.String(), tt.want) {
+				t.Errorf("printStuckPayload() = %s, want %q", w.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/xiang-xx/oparse/config"
+	"github.com/xiang-xx/oparse/xabi"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// swapCall 解析后的 SwapData.CallData，即 SoDiamond 对 swap router 的调用
+type swapCall struct {
+	Router       config.UniswapRouter
+	Method       string
+	Path         []common.Address
+	Fees         []int    // V3 path 中每一跳的手续费，V2 为空
+	AmountIn     *big.Int // swapExactETH* 使用 SwapData.FromAmount 作为输入数量时为 nil
+	AmountOutMin *big.Int
+	Recipient    common.Address
+	Deadline     *big.Int
+}
+
+// decodeSwapCall 按 router 类型解析 swap 调用数据，不支持的 router 类型返回 nil
+func decodeSwapCall(router config.UniswapRouter, swapItem SwapData) (*swapCall, error) {
+	var swapAbi *abi.ABI
+	switch router.Type {
+	case "IUniswapV2Router02":
+		swapAbi = &xabi.IUniswapV2Router02
+	case "IUniswapV2Router02AVAX":
+		swapAbi = &xabi.IUniswapV2Router02AVAX
+	case "ISwapRouter":
+		swapAbi = &xabi.ISwapRouter
+	default:
+		return nil, nil
+	}
+	if len(swapItem.CallData) < 4 {
+		return nil, fmt.Errorf("invalid swap call data length %d", len(swapItem.CallData))
+	}
+	method, err := swapAbi.MethodById(swapItem.CallData[:4])
+	if err != nil {
+		return nil, err
+	}
+	call := &swapCall{Router: router, Method: method.RawName}
+	if router.Type == "ISwapRouter" {
+		res := &SwapV3InputData{}
+		err = decodeMethodInput(method, swapItem.CallData[4:], res)
+		if err != nil {
+			return nil, err
+		}
+		call.Path, call.Fees, err = decodePath(res.ExactInputParams.Path)
+		if err != nil {
+			return nil, err
+		}
+		call.AmountIn = res.ExactInputParams.AmountIn
+		call.AmountOutMin = res.ExactInputParams.AmountOutMinimum
+		call.Recipient = res.ExactInputParams.Recipient
+		call.Deadline = res.ExactInputParams.Deadline
+		return call, nil
+	}
+
+	if strings.HasPrefix(method.RawName, "swapExactTokens") {
+		res := &FromTokenSwapInputData{}
+		err = decodeMethodInput(method, swapItem.CallData[4:], res)
+		if err != nil {
+			return nil, err
+		}
+		call.Path, call.AmountIn, call.AmountOutMin, call.Recipient, call.Deadline = res.Path, res.AmountIn, res.AmountOutMin, res.To, res.Deadline
+	} else {
+		res := &FromBalanceSwapInputData{}
+		err = decodeMethodInput(method, swapItem.CallData[4:], res)
+		if err != nil {
+			return nil, err
+		}
+		call.Path, call.AmountOutMin, call.Recipient, call.Deadline = res.Path, res.AmountOutMin, res.To, res.Deadline
+	}
+	if len(call.Path) == 0 {
+		return nil, errors.New("empty swap path")
+	}
+	return call, nil
+}
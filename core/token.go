@@ -1,11 +1,19 @@
 package core
 
 import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
 	"github.com/xiang-xx/oparse/config"
+	"github.com/xiang-xx/oparse/xabi"
 
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/fatih/color"
 )
 
 type Token struct {
@@ -13,49 +21,118 @@ type Token struct {
 	Symbol   string
 	Decimals int
 	Address  string
+	LogoURI  string
+	Label    string // token 来源，token list 名称或 Stargate
+	Listed   bool   // 是否在本地 token list 或 Stargate pool 中
 }
 
-var tokenCache map[string]Token
+var (
+	tokenCache     map[string]Token
+	tokenCacheLock sync.RWMutex
+)
+
+// tokenMetadataMethods 获取 token 信息时调用的方法，buildToken 依赖此顺序
+var tokenMetadataMethods = []string{"symbol", "name", "decimals"}
 
 func init() {
 	tokenCache = make(map[string]Token, 0)
 }
 
-func getTokenInfo(client *ethclient.Client, chain *config.ChainInfo, tokenAddress common.Address) (token Token, err error) {
+func getCachedToken(key string) (Token, bool) {
+	tokenCacheLock.RLock()
+	defer tokenCacheLock.RUnlock()
+	token, ok := tokenCache[key]
+	return token, ok
+}
+
+func setCachedToken(key string, token Token) {
+	tokenCacheLock.Lock()
+	defer tokenCacheLock.Unlock()
+	tokenCache[key] = token
+}
+
+func (d *Decoder) getTokenInfo(ctx context.Context, chain *config.ChainInfo, tokenAddress common.Address) (token Token, err error) {
+	if isZeroAddress(tokenAddress) {
+		return nativeToken(chain), nil
+	}
+	if token, ok := lookupListedToken(chain, tokenAddress); ok {
+		return labelWrappedNative(chain, token), nil
+	}
 	cacheKey := chain.ChainName + tokenAddress.Hex()
-	if token, ok := tokenCache[cacheKey]; ok {
-		return token, nil
+	if token, ok := getCachedToken(cacheKey); ok {
+		return labelWrappedNative(chain, token), nil
 	}
-	if isZeroAddress(tokenAddress) {
-		return Token{
-			Name:     chain.CurrancySymbol,
-			Symbol:   chain.CurrancySymbol,
-			Decimals: 18,
-			Address:  tokenAddress.String(),
-		}, nil
-	}
-	instance, err := NewStore(tokenAddress, client)
+	client, err := d.client(chain)
 	if err != nil {
-		return
+		return token, err
 	}
-	symbol, err := instance.Symbol(&bind.CallOpts{})
-	if err != nil {
-		return
+	returns := make([][]byte, len(tokenMetadataMethods))
+	for i, m := range tokenMetadataMethods {
+		callData, err := xabi.ERC20.Pack(m)
+		if err != nil {
+			return token, err
+		}
+		callCtx, cancel := d.callContext(ctx)
+		returns[i], err = client.CallContract(callCtx, ethereum.CallMsg{
+			To:   &tokenAddress,
+			Data: callData,
+		}, nil)
+		cancel()
+		// 方法不存在时节点返回 revert 错误，视为该字段缺失；限流、节点故障等其他错误直接返回，不缓存
+		if err != nil {
+			if _, ok := revertReason(err); !ok {
+				return token, err
+			}
+		}
 	}
-	name, err := instance.Name(&bind.CallOpts{})
-	if err != nil {
-		return
+	token, ok := buildToken(tokenAddress, returns)
+	if ok {
+		setCachedToken(cacheKey, token)
 	}
-	decimals, err := instance.Decimals(&bind.CallOpts{})
-	if err != nil {
-		return
+	return labelWrappedNative(chain, token), nil
+}
+
+// nativeToken 链的原生币，SoData/SwapData 中用 0 地址表示
+func nativeToken(chain *config.ChainInfo) Token {
+	return Token{
+		Name:     chain.NativeCurrency.Name,
+		Symbol:   chain.NativeCurrency.Symbol,
+		Decimals: chain.NativeCurrency.Decimals,
+		Address:  common.Address{}.String(),
+		Label:    "native",
+		Listed:   true,
 	}
-	token.Name = name
-	token.Symbol = symbol
-	token.Decimals = int(decimals)
-	token.Address = tokenAddress.String()
-	tokenCache[cacheKey] = token
-	return
+}
+
+// wrappedNativeAddress 链配置的 wrapped native token 地址，未配置时返回 0 地址
+func wrappedNativeAddress(chain *config.ChainInfo) common.Address {
+	if !common.IsHexAddress(chain.WETH) {
+		return common.Address{}
+	}
+	return common.HexToAddress(chain.WETH)
+}
+
+func isWrappedNative(chain *config.ChainInfo, address common.Address) bool {
+	return !isZeroAddress(address) && address == wrappedNativeAddress(chain)
+}
+
+// labelWrappedNative 标记 wrapped native token
+func labelWrappedNative(chain *config.ChainInfo, token Token) Token {
+	if isWrappedNative(chain, common.HexToAddress(token.Address)) {
+		token.Label = "wrapped native"
+	}
+	return token
+}
+
+// assetLabel 输出 SoData 中资产地址时附加的说明，区分原生币与 wrapped native token
+func assetLabel(chain *config.ChainInfo, address common.Address) string {
+	if isZeroAddress(address) {
+		return "native " + chain.NativeCurrency.Symbol
+	}
+	if isWrappedNative(chain, address) {
+		return "wrapped native"
+	}
+	return ""
 }
 
 func isZeroAddress(address common.Address) bool {
@@ -67,3 +144,155 @@ func isZeroAddress(address common.Address) bool {
 	}
 	return true
 }
+
+// getTokenInfos 批量获取 token 信息，未缓存的 token 通过 Multicall3 一次查询 symbol/name/decimals
+// Multicall3 不可用时，回退到逐个查询
+func (d *Decoder) getTokenInfos(ctx context.Context, chain *config.ChainInfo, tokenAddresses []common.Address) ([]Token, error) {
+	pending := make([]common.Address, 0)
+	for _, tokenAddress := range tokenAddresses {
+		if isZeroAddress(tokenAddress) {
+			continue
+		}
+		if _, ok := lookupListedToken(chain, tokenAddress); ok {
+			continue
+		}
+		if _, ok := getCachedToken(chain.ChainName + tokenAddress.Hex()); ok {
+			continue
+		}
+		pending = append(pending, tokenAddress)
+	}
+
+	var fetched map[common.Address]Token
+	if len(pending) > 0 {
+		fetched = d.fetchTokenInfos(ctx, chain, pending)
+	}
+
+	tokens := make([]Token, len(tokenAddresses))
+	for i, tokenAddress := range tokenAddresses {
+		if token, ok := fetched[tokenAddress]; ok {
+			tokens[i] = labelWrappedNative(chain, token)
+			continue
+		}
+		token, err := d.getTokenInfo(ctx, chain, tokenAddress)
+		if err != nil {
+			return nil, err
+		}
+		tokens[i] = token
+	}
+	return tokens, nil
+}
+
+// fetchTokenInfos 通过 Multicall3 查询 token 信息，Multicall3 调用失败时返回 nil
+func (d *Decoder) fetchTokenInfos(ctx context.Context, chain *config.ChainInfo, tokenAddresses []common.Address) map[common.Address]Token {
+	client, err := d.client(chain)
+	if err != nil {
+		return nil
+	}
+	calls := make([]call3, 0, len(tokenAddresses)*len(tokenMetadataMethods))
+	for _, tokenAddress := range tokenAddresses {
+		for _, m := range tokenMetadataMethods {
+			callData, err := xabi.ERC20.Pack(m)
+			if err != nil {
+				return nil
+			}
+			calls = append(calls, call3{
+				Target:       tokenAddress,
+				AllowFailure: true,
+				CallData:     callData,
+			})
+		}
+	}
+	callCtx, cancel := d.callContext(ctx)
+	defer cancel()
+	results, err := multicall(callCtx, client, calls)
+	if err != nil || len(results) != len(calls) {
+		return nil
+	}
+
+	tokens := make(map[common.Address]Token, len(tokenAddresses))
+	for i, tokenAddress := range tokenAddresses {
+		returns := make([][]byte, len(tokenMetadataMethods))
+		for j := range tokenMetadataMethods {
+			if r := results[i*len(tokenMetadataMethods)+j]; r.Success {
+				returns[j] = r.ReturnData
+			}
+		}
+		token, ok := buildToken(tokenAddress, returns)
+		if ok {
+			setCachedToken(chain.ChainName+tokenAddress.Hex(), token)
+		}
+		tokens[tokenAddress] = token
+	}
+	return tokens
+}
+
+// buildToken 根据 symbol/name/decimals 的返回数据构造 Token，兼容 bytes32 类型的 symbol/name（如 MKR）
+// symbol 缺失时使用缩写地址，name 缺失时使用 symbol，decimals 缺失时按 0 处理（显示原始数量）
+// 返回的 bool 表示 symbol 和 decimals 是否都正常获取，只有正常获取的 token 才应被缓存
+func buildToken(tokenAddress common.Address, returns [][]byte) (Token, bool) {
+	token := Token{
+		Address: tokenAddress.String(),
+	}
+	symbol, symbolOk := decodeTokenString(returns[0])
+	if !symbolOk {
+		symbol = shortAddress(tokenAddress)
+	}
+	token.Symbol = symbol
+	if name, ok := decodeTokenString(returns[1]); ok {
+		token.Name = name
+	} else {
+		token.Name = symbol
+	}
+	decimals, decimalsOk := decodeTokenDecimals(returns[2])
+	token.Decimals = decimals
+	return token, symbolOk && decimalsOk
+}
+
+func decodeTokenString(data []byte) (string, bool) {
+	if values, err := xabi.ERC20.Unpack("symbol", data); err == nil && len(values) == 1 {
+		if s, ok := values[0].(string); ok && s != "" {
+			return s, true
+		}
+	}
+	// bytes32 返回值
+	if len(data) == 32 {
+		s := strings.TrimSpace(string(bytes.TrimRight(data, "\x00")))
+		if s != "" && utf8.ValidString(s) {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// decodeTokenDecimals 兼容 uint8 与 uint256 类型的 decimals 返回值
+func decodeTokenDecimals(data []byte) (int, bool) {
+	if len(data) < 32 {
+		return 0, false
+	}
+	decimals := new(big.Int).SetBytes(data[:32])
+	if !decimals.IsUint64() || decimals.Uint64() > 255 {
+		return 0, false
+	}
+	return int(decimals.Uint64()), true
+}
+
+// formatTokenDetail 输出 token 地址、来源及 token list 中的 logo，加载了 token list 时标记不在列表中的 token
+func formatTokenDetail(token Token) string {
+	content := alignString(token.Symbol, 7) + token.Address
+	if token.Label != "" {
+		content = content + "  " + token.Label
+	}
+	if token.LogoURI != "" {
+		content = content + "  logo " + token.LogoURI
+	}
+	if tokenListLoaded() && !token.Listed {
+		content = content + "  " + color.HiRedString("unlisted")
+	}
+	return content
+}
+
+// shortAddress 0x1234...abcd
+func shortAddress(address common.Address) string {
+	hex := address.Hex()
+	return hex[:6] + "..." + hex[len(hex)-4:]
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/xiang-xx/oparse/xabi"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func Test_buildToken(t *testing.T) {
+	tokenAddress := common.HexToAddress("0x9f8f72aa9304c8b593d555f12ef6589cc3a579a2")
+	stringSymbol, _ := xabi.ERC20.Methods["symbol"].Outputs.Pack("USDC")
+	bytes32Symbol := common.RightPadBytes([]byte("MKR"), 32)
+	bytes32Name := common.RightPadBytes([]byte("Maker"), 32)
+	decimals := common.LeftPadBytes([]byte{18}, 32)
+
+	tests := []struct {
+		name    string
+		returns [][]byte
+		want    Token
+		wantOk  bool
+	}{
+		{
+			name:    "string metadata",
+			returns: [][]byte{stringSymbol, stringSymbol, decimals},
+			want:    Token{Name: "USDC", Symbol: "USDC", Decimals: 18, Address: tokenAddress.String()},
+			wantOk:  true,
+		},
+		{
+			name:    "bytes32 metadata",
+			returns: [][]byte{bytes32Symbol, bytes32Name, decimals},
+			want:    Token{Name: "Maker", Symbol: "MKR", Decimals: 18, Address: tokenAddress.String()},
+			wantOk:  true,
+		},
+		{
+			name:    "missing name",
+			returns: [][]byte{bytes32Symbol, nil, decimals},
+			want:    Token{Name: "MKR", Symbol: "MKR", Decimals: 18, Address: tokenAddress.String()},
+			wantOk:  true,
+		},
+		{
+			name:    "not a token",
+			returns: [][]byte{nil, nil, nil},
+			want:    Token{Name: "0x9f8F...79A2", Symbol: "0x9f8F...79A2", Decimals: 0, Address: tokenAddress.String()},
+			wantOk:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotOk := buildToken(tokenAddress, tt.returns)
+			if got != tt.want {
+				t.Errorf("buildToken() got = %v, want %v", got, tt.want)
+			}
+			if gotOk != tt.wantOk {
+				t.Errorf("buildToken() gotOk = %v, want %v", gotOk, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_formatTokenDetail(t *testing.T) {
+	tests := []struct {
+		name  string
+		token Token
+		want  string
+	}{
+		{
+			name:  "without logo",
+			token: Token{Symbol: "USDC", Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", Label: "Stargate", Listed: true},
+			want:  "USDC   0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48  Stargate",
+		},
+		{
+			name:  "with logo",
+			token: Token{Symbol: "USDC", Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", Label: "Uniswap Labs Default", LogoURI: "ipfs://usdc.png", Listed: true},
+			want:  "USDC   0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48  Uniswap Labs Default  logo ipfs://usdc.png",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatTokenDetail(tt.token); got != tt.want {
+				t.Errorf("formatTokenDetail() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
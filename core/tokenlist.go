@@ -0,0 +1,97 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/xiang-xx/oparse/config"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TokenList Uniswap 格式的 token list，https://tokenlists.org
+type TokenList struct {
+	Name   string          `json:"name"`
+	Tokens []TokenListItem `json:"tokens"`
+}
+
+type TokenListItem struct {
+	ChainId  int      `json:"chainId"`
+	Address  string   `json:"address"`
+	Name     string   `json:"name"`
+	Symbol   string   `json:"symbol"`
+	Decimals int      `json:"decimals"`
+	LogoURI  string   `json:"logoURI"`
+	Tags     []string `json:"tags"`
+}
+
+// listedTokens 本地 token list 中的 token，key 为 chainId + 小写地址
+var listedTokens map[string]Token
+
+func init() {
+	listedTokens = make(map[string]Token, 0)
+}
+
+func listedTokenKey(chainId int, address string) string {
+	return fmt.Sprintf("%d%s", chainId, strings.ToLower(address))
+}
+
+// LoadTokenList 加载本地 Uniswap 格式的 token list 文件，加载后 getTokenInfo 优先从中查询 token 信息，
+// 不在 token list 和 Stargate pool 中的 token 会被标记为 unlisted
+func LoadTokenList(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	list := TokenList{}
+	err = json.Unmarshal(content, &list)
+	if err != nil {
+		return fmt.Errorf("parse token list %s: %w", path, err)
+	}
+	for _, item := range list.Tokens {
+		if !common.IsHexAddress(item.Address) {
+			continue
+		}
+		label := list.Name
+		if len(item.Tags) > 0 {
+			label = label + "(" + strings.Join(item.Tags, ",") + ")"
+		}
+		listedTokens[listedTokenKey(item.ChainId, item.Address)] = Token{
+			Name:     item.Name,
+			Symbol:   item.Symbol,
+			Decimals: item.Decimals,
+			Address:  common.HexToAddress(item.Address).String(),
+			LogoURI:  item.LogoURI,
+			Label:    label,
+			Listed:   true,
+		}
+	}
+	return nil
+}
+
+// lookupListedToken 从本地 token list 和链配置中的 Stargate pool token 查询 token 信息
+func lookupListedToken(chain *config.ChainInfo, tokenAddress common.Address) (Token, bool) {
+	if token, ok := listedTokens[listedTokenKey(chain.ChainId, tokenAddress.Hex())]; ok {
+		return token, true
+	}
+	for _, pool := range chain.StargatePool {
+		if strings.EqualFold(pool.TokenAddress, tokenAddress.Hex()) {
+			return Token{
+				Name:     pool.TokenName,
+				Symbol:   pool.TokenName,
+				Decimals: pool.Decimal,
+				Address:  tokenAddress.String(),
+				Label:    "Stargate",
+				Listed:   true,
+			}, true
+		}
+	}
+	return Token{}, false
+}
+
+// tokenListLoaded 是否加载了本地 token list，未加载时不标记 unlisted
+func tokenListLoaded() bool {
+	return len(listedTokens) > 0
+}
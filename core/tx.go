@@ -6,26 +6,23 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"math/big"
-	"strconv"
 	"strings"
+	"time"
 
 	"github.com/xiang-xx/oparse/config"
 	"github.com/xiang-xx/oparse/xabi"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/fatih/color"
-	"github.com/shopspring/decimal"
 )
 
 const alignment = 25
 
-var withDetail = false
-
 // SoData 代表单笔 swap 数据，用于链路 swap 追踪
 type SoData struct {
 	TransactionId      [32]byte // 唯一交易ID， 32字节
@@ -59,6 +56,37 @@ type StargateData struct {
 	DstSoDiamond       common.Address // 目的链 SoDiamond 地址
 }
 
+// soCall SoDiamond 调用的上下文，链上交易取自交易及其所在区块，未签名的 calldata 取自命令行参数
+type soCall struct {
+	chain   *config.ChainInfo
+	from    common.Address
+	value   *big.Int
+	time    time.Time      // 交易所在区块的时间，未打包时为当前时间，用于检查 deadline
+	receipt *types.Receipt // 交易未打包或未签名时为 nil
+	// dstTxHash 跨链交易在目标链上的交易 hash，为空时不查询目标链交易
+	dstTxHash string
+}
+
+// swapEnv 输出 swap 时的参照，quoteBlock 为查询预计输出的区块，nil 表示 latest
+type swapEnv struct {
+	deadline   deadlineRef
+	quoteBlock *big.Int
+}
+
+// srcSwapEnv 源链 swap 以交易所在区块的前一个区块（交易执行前的状态）报价，未打包时使用 latest
+func (c soCall) srcSwapEnv() swapEnv {
+	env := swapEnv{deadline: deadlineRef{at: c.time}}
+	if c.receipt != nil && c.receipt.BlockNumber != nil && c.receipt.BlockNumber.Sign() > 0 {
+		env.quoteBlock = new(big.Int).Sub(c.receipt.BlockNumber, big.NewInt(1))
+	}
+	return env
+}
+
+// dstSwapEnv 目标链 swap 在 Stargate 消息到达后执行，以目标链 latest 报价
+func (c soCall) dstSwapEnv(fromChain *config.ChainInfo) swapEnv {
+	return swapEnv{deadline: deadlineRef{at: c.time, delivery: expectedDeliveryTime(fromChain)}}
+}
+
 type GenericInputData struct {
 	SoData   SoData
 	SwapData []SwapData
@@ -98,105 +126,204 @@ type ExactInputParams struct {
 	AmountOutMinimum *big.Int
 }
 
-type MyReceipt struct {
-	// types.Receipt
-	ReturnCode string `json:"returnCode"`
-	ReturnData string `json:"returnData"`
+// FindTx 查询链上交易，交易不存在时返回 ethereum.NotFound
+func (d *Decoder) FindTx(ctx context.Context, chain *config.ChainInfo, txHash string) (*types.Transaction, error) {
+	client, err := d.client(chain)
+	if err != nil {
+		return nil, fmt.Errorf("dail rpc %s error: %w", chain.Rpc, err)
+	}
+	callCtx, cancel := d.callContext(ctx)
+	defer cancel()
+	tx, _, err := client.TransactionByHash(callCtx, common.HexToHash(txHash))
+	return tx, err
 }
 
-func ParseTxOnChain(chain *config.ChainInfo, txHash string, d bool) {
-	withDetail = d
-	ctx := context.Background()
-	client, err := ethclient.Dial(chain.Rpc)
+// PrintTx 输出交易基础信息及 SoDiamond 调用数据，返回交易 receipt，交易未打包时 receipt 为 nil，获取 receipt 出错时同时返回该错误
+// dstTxHash 为 Stargate 跨链交易在目标链上的交易 hash，可以为空
+func (d *Decoder) PrintTx(ctx context.Context, w io.Writer, chain *config.ChainInfo, tx *types.Transaction, dstTxHash string) (*types.Receipt, error) {
+	client, err := d.client(chain)
 	if err != nil {
-		fmt.Printf("dail rpc %s error: %s\n", chain.Rpc, err)
-		return
+		printError(w, "dial rpc", err)
+		return nil, err
 	}
-	// 调用 rpc 获取 交易数据
-	hash := common.HexToHash(txHash)
-	tx, _, err := client.TransactionByHash(ctx, hash)
-	if err != nil {
-		if err.Error() == "not found" {
-			return
+	hash := tx.Hash()
+
+	printLine(w)
+	printAlignLine(w, "Chain", chain.ChainName)
+
+	callCtx, cancel := d.callContext(ctx)
+	receipt, receiptErr := client.TransactionReceipt(callCtx, hash)
+	cancel()
+	if errors.Is(receiptErr, ethereum.NotFound) {
+		receiptErr = nil
+	}
+	if receiptErr != nil {
+		printError(w, "get receipt", receiptErr)
+	}
+	var rawReceipt *MyReceipt
+	var header *types.Header
+	if receipt != nil {
+		rawReceipt, err = d.getRawReceipt(ctx, chain, hash)
+		if err != nil {
+			printError(w, "eth_getTransactionReceipt", err)
+		}
+		callCtx, cancel := d.callContext(ctx)
+		header, err = client.HeaderByNumber(callCtx, receipt.BlockNumber)
+		cancel()
+		if err != nil {
+			printError(w, "get block", err)
 		}
-		printError("get tx", err)
-		return
 	}
 
-	printLine()
-	printAlignLine("Chain", chain.ChainName)
+	printLine(w)
+	d.printTxBaseInfo(w, chain, tx, receipt, rawReceipt, header)
+	printReceipt(w, receipt, rawReceipt)
+	printLine(w)
 
-	receipt, err := client.TransactionReceipt(ctx, hash)
-	if err != nil {
-		printError("get receipt", err)
+	call := soCall{chain: chain, value: tx.Value(), time: time.Now(), receipt: receipt, dstTxHash: dstTxHash}
+	if from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx); err == nil {
+		call.from = from
 	}
+	if header != nil {
+		call.time = time.Unix(int64(header.Time), 0)
+	}
+	d.printTxInput(ctx, w, call, tx)
+	return receipt, receiptErr
+}
 
-	printLine()
-	printTxBaseInfo(chain, tx)
-	printReceipt(receipt, chain, hash)
-	printLine()
-
+// printTxInput 解析并输出 SoDiamond 调用数据，非 SoDiamond 交易只输出交易类型
+func (d *Decoder) printTxInput(ctx context.Context, w io.Writer, call soCall, tx *types.Transaction) {
+	chain := call.chain
 	inputData := tx.Data()
+	if tx.To() == nil {
+		printAlignLine(w, "TxKind", "contract creation")
+		return
+	}
+	if !strings.EqualFold(tx.To().Hex(), chain.SoDiamond) {
+		if len(inputData) == 0 {
+			printAlignLine(w, "TxKind", "plain transfer to "+tx.To().Hex()+", not a SoDiamond tx")
+		} else {
+			printAlignLine(w, "TxKind", "contract call to "+tx.To().Hex()+", not a SoDiamond tx")
+		}
+		return
+	}
+	if len(inputData) == 0 {
+		printAlignLine(w, "TxKind", "plain transfer to SoDiamond")
+		return
+	}
+	if len(inputData) < 4 {
+		printError(w, "TxKind", fmt.Errorf("invalid input data length %d", len(inputData)))
+		return
+	}
 	method, err := xabi.SoDiamond.MethodById(inputData[:4])
 	if err != nil {
-		printError("MethodById", err)
+		printAlignLine(w, "TxKind", "unknown SoDiamond method 0x"+hex.EncodeToString(inputData[:4]))
 		return
 	}
+	printAlignLine(w, "TxKind", "SoDiamond "+method.RawName)
 
 	if method.RawName == "swapTokensGeneric" {
-		err = parseSwapTokenGeneric(client, method, inputData[4:])
+		err = d.parseSwapTokenGeneric(ctx, w, call, method, inputData[4:])
 		if err != nil {
-			printError("parseSwapTokenGeneric", err)
+			printError(w, "parseSwapTokenGeneric", err)
 		}
 	} else if method.RawName == "soSwapViaStargate" {
-		err = parseSoSwapViaStargate(client, method, inputData[4:])
+		err = d.parseSoSwapViaStargate(ctx, w, call, method, inputData[4:])
 		if err != nil {
-			printError("soSwapViaStargate", err)
+			printError(w, "soSwapViaStargate", err)
 		}
 	}
 }
 
-func parseSoSwapViaStargate(client *ethclient.Client, method *abi.Method, methodInput []byte) error {
+// decodeSoDiamondCall 解析 SoDiamond calldata，返回 *SoSwapViaStargateInputData 或 *GenericInputData
+func decodeSoDiamondCall(input []byte) (interface{}, error) {
+	if len(input) < 4 {
+		return nil, fmt.Errorf("invalid input data length %d", len(input))
+	}
+	method, err := xabi.SoDiamond.MethodById(input[:4])
+	if err != nil {
+		return nil, errors.New("unknown SoDiamond method 0x" + hex.EncodeToString(input[:4]))
+	}
+	var out interface{}
+	switch method.RawName {
+	case "soSwapViaStargate":
+		out = &SoSwapViaStargateInputData{}
+	case "swapTokensGeneric":
+		out = &GenericInputData{}
+	default:
+		return nil, errors.New("unsupported SoDiamond method " + method.RawName)
+	}
+	if err := decodeMethodInput(method, input[4:], out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// decodeMethodInput 解析方法参数到 out 结构体
+func decodeMethodInput(method *abi.Method, methodInput []byte, out interface{}) error {
 	values, err := method.Inputs.UnpackValues(methodInput)
 	if err != nil {
 		return err
 	}
+	return method.Inputs.Copy(out, values)
+}
+
+func (d *Decoder) parseSoSwapViaStargate(ctx context.Context, w io.Writer, call soCall, method *abi.Method, methodInput []byte) error {
 	inputStructData := &SoSwapViaStargateInputData{}
-	err = method.Inputs.Copy(inputStructData, values)
+	err := decodeMethodInput(method, methodInput, inputStructData)
 	if err != nil {
 		return err
 	}
-	printSoData(inputStructData.SoData)
+	d.printSoData(ctx, w, inputStructData.SoData)
 	fromChain := config.GetChainByChainId(int(inputStructData.SoData.SourceChainId.Int64()))
 	if nil == fromChain {
 		return errors.New("not found from chain")
 	}
 	toChain := config.GetChainByChainId(int(inputStructData.SoData.DestinationChainId.Int64()))
-	if nil == fromChain {
+	if nil == toChain {
 		return errors.New("not found to chain")
 	}
 
-	printSwapData("SrcSwap", fromChain, inputStructData.SwapDataSrc)
+	d.printSwapData(ctx, w, "SrcSwap", fromChain, call.srcSwapEnv(), inputStructData.SwapDataSrc)
 
-	printStargateData(fromChain, toChain, inputStructData.StargateData)
+	d.printStargateData(w, fromChain, toChain, inputStructData.StargateData)
 
-	printSwapData("DstSwap", toChain, inputStructData.SwapDataDst)
+	d.printSwapData(ctx, w, "DstSwap", toChain, call.dstSwapEnv(fromChain), inputStructData.SwapDataDst)
+
+	var dstReceipt *types.Receipt
+	if call.dstTxHash != "" {
+		dstReceipt, err = d.dstReceipt(ctx, toChain, call.dstTxHash)
+		if err != nil {
+			printError(w, "get dst tx on "+toChain.ChainName, err)
+		} else if err := matchDstReceipt(toChain, call.receipt, dstReceipt); errors.Is(err, errDstUnverified) {
+			printAlignLine(w, "DstTx", color.HiYellowString("warning: %s", err))
+		} else if err != nil {
+			// 目标链交易不属于这笔源链交易时不使用
+			printError(w, "dst tx", err)
+			dstReceipt = nil
+		}
+	}
+	d.printSlippage(ctx, w, call, toChain, inputStructData.SoData, inputStructData.SwapDataSrc, &inputStructData.StargateData, inputStructData.SwapDataDst, dstReceipt)
+	d.printFeeBreakdown(ctx, w, call, fromChain, toChain, inputStructData)
+	d.printDstGas(ctx, w, toChain, inputStructData, dstReceipt)
+	d.printStuckPayload(ctx, w, call, toChain, dstReceipt)
 
+	d.printWarnings(w, "Reconcile", d.reconcileStargate(ctx, fromChain, toChain, call.value, inputStructData))
+	printFindings(w, "Lint", lintSoSwapViaStargate(call, inputStructData))
 	return nil
 }
 
-func printStargateData(fromChain, toChain *config.ChainInfo, stargateData StargateData) {
+func (d *Decoder) printStargateData(w io.Writer, fromChain, toChain *config.ChainInfo, stargateData StargateData) {
 	stargatePath := ""
 	var fromToken Token
-	for _, pool := range fromChain.StargatePool {
-		if pool.PoolId == int(stargateData.SrcStargatePoolId.Int64()) {
-			stargatePath = stargatePath + fmt.Sprintf("%s(%d)", pool.TokenName, pool.PoolId)
-			fromToken = Token{
-				Address:  pool.TokenAddress,
-				Symbol:   pool.TokenName,
-				Decimals: pool.Decimal,
-				Name:     pool.TokenName,
-			}
+	srcPool := config.GetStargatePool(fromChain, int(stargateData.SrcStargatePoolId.Int64()))
+	if srcPool != nil {
+		stargatePath = stargatePath + fmt.Sprintf("%s(%d)", srcPool.TokenName, srcPool.PoolId)
+		fromToken = Token{
+			Address:  srcPool.TokenAddress,
+			Symbol:   srcPool.TokenName,
+			Decimals: srcPool.Decimal,
+			Name:     srcPool.TokenName,
 		}
 	}
 
@@ -205,23 +332,25 @@ func printStargateData(fromChain, toChain *config.ChainInfo, stargateData Starga
 			stargatePath = stargatePath + fmt.Sprintf(" -> %s(%d)", pool.TokenName, pool.PoolId)
 		}
 	}
-	printAlignLine("Stargate", stargatePath)
+	printAlignLine(w, "Stargate", stargatePath)
+	// 源 pool 的 ChainPath 是否包含目标 pool
+	if srcPool != nil && srcPool.SupportsPath(int(stargateData.DstStargateChainId), int(stargateData.DstStargatePoolId.Int64())) {
+		printAlignLine(w, "", alignString("ChainPath", 10)+"supported")
+	} else {
+		printAlignLine(w, "", alignString("ChainPath", 10)+color.HiRedString("not supported, run oparse routes -from %s -to %s", fromChain.ChainName, toChain.ChainName))
+	}
 	// min amount
-	printAlignLine("", alignString("MinAmount", 10)+formatToken(stargateData.MinAmount.String(), fromToken))
-	printAlignLine("", alignString("DstGas", 10)+stargateData.DstGasForSgReceive.String())
+	printAlignLine(w, "", alignString("MinAmount", 10)+d.formatToken(stargateData.MinAmount, fromToken))
+	printAlignLine(w, "", alignString("DstGas", 10)+stargateData.DstGasForSgReceive.String())
 }
 
-func parseSwapTokenGeneric(client *ethclient.Client, method *abi.Method, methodInput []byte) error {
-	values, err := method.Inputs.UnpackValues(methodInput)
-	if err != nil {
-		return err
-	}
+func (d *Decoder) parseSwapTokenGeneric(ctx context.Context, w io.Writer, call soCall, method *abi.Method, methodInput []byte) error {
 	inputStructData := &GenericInputData{}
-	err = method.Inputs.Copy(inputStructData, values)
+	err := decodeMethodInput(method, methodInput, inputStructData)
 	if err != nil {
 		return err
 	}
-	err = printSoData(inputStructData.SoData)
+	err = d.printSoData(ctx, w, inputStructData.SoData)
 	if err != nil {
 		return err
 	}
@@ -230,194 +359,144 @@ func parseSwapTokenGeneric(client *ethclient.Client, method *abi.Method, methodI
 		return errors.New("not found from chain")
 	}
 
-	return printSwapData("SrcChain", fromChain, inputStructData.SwapData)
+	err = d.printSwapData(ctx, w, "SrcChain", fromChain, call.srcSwapEnv(), inputStructData.SwapData)
+	if err != nil {
+		return err
+	}
+	d.printSlippage(ctx, w, call, nil, inputStructData.SoData, inputStructData.SwapData, nil, nil, nil)
+	d.printWarnings(w, "Reconcile", d.reconcileGeneric(ctx, fromChain, call.value, inputStructData))
+	printFindings(w, "Lint", lintSwapTokensGeneric(call, inputStructData))
+	return nil
 }
 
-func printSwapData(where string, chain *config.ChainInfo, swapData []SwapData) error {
+func (d *Decoder) printSwapData(ctx context.Context, w io.Writer, where string, chain *config.ChainInfo, env swapEnv, swapData []SwapData) error {
 	if len(swapData) == 0 {
-		printAlignLine(where, "Not Swapped")
+		printAlignLine(w, where, "Not Swapped")
 	}
 	for _, swapItem := range swapData {
 		callTo := swapItem.CallTo.String()
 		for _, r := range chain.UniswapRouter {
 			if r.RouterAddress == callTo {
-				printSwapItem(where, chain, r, swapItem)
+				if err := d.printSwapItem(ctx, w, where, chain, env, r, swapItem); err != nil {
+					printError(w, where, err)
+				}
 			}
 		}
 	}
 	return nil
 }
 
-func printSwapItem(where string, chain *config.ChainInfo, router config.UniswapRouter, swapItem SwapData) error {
-	if router.Type == "IUniswapV2Router02" || router.Type == "IUniswapV2Router02AVAX" {
-		return printSwapV2Item(where, chain, router, swapItem)
-	} else if router.Type == "ISwapRouter" {
-		return printSwapV3Item(where, chain, router, swapItem)
-	}
-	return nil
-}
-
-func printSwapV2Item(where string, chain *config.ChainInfo, router config.UniswapRouter, swapItem SwapData) error {
-	var swapAbi *abi.ABI
-	if router.Type == "IUniswapV2Router02" {
-		swapAbi = &xabi.IUniswapV2Router02
-	} else {
-		swapAbi = &xabi.IUniswapV2Router02AVAX
-	}
-
-	method, err := swapAbi.MethodById(swapItem.CallData[:4])
-	if err != nil {
+func (d *Decoder) printSwapItem(ctx context.Context, w io.Writer, where string, chain *config.ChainInfo, env swapEnv, router config.UniswapRouter, swapItem SwapData) error {
+	call, err := decodeSwapCall(router, swapItem)
+	if err != nil || nil == call {
 		return err
 	}
-	inputValues, err := method.Inputs.Unpack(swapItem.CallData[4:])
-	if err != nil {
-		return err
+	if router.Type == "ISwapRouter" {
+		return d.printSwapV3Item(ctx, w, where, chain, env, call, swapItem)
 	}
+	return d.printSwapV2Item(ctx, w, where, chain, env, call, swapItem)
+}
 
-	var swapPath []common.Address
-	var amoutOutMin *big.Int
-	if strings.HasPrefix(method.RawName, "swapExactTokens") {
-		res := &FromTokenSwapInputData{}
-		err = method.Inputs.Copy(res, inputValues)
-		if err != nil {
-			return err
-		}
-		swapPath = res.Path
-		amoutOutMin = res.AmountOutMin
-	} else {
-		res := &FromBalanceSwapInputData{}
-		err = method.Inputs.Copy(res, inputValues)
-		if err != nil {
-			return err
-		}
-		swapPath = res.Path
-		amoutOutMin = res.AmountOutMin
-	}
-	client, err := ethclient.Dial(chain.Rpc)
+func (d *Decoder) printSwapV2Item(ctx context.Context, w io.Writer, where string, chain *config.ChainInfo, env swapEnv, call *swapCall, swapItem SwapData) error {
+	tokens, err := d.getTokenInfos(ctx, chain, call.Path)
 	if err != nil {
 		return err
 	}
 	paths := []string{}
-	tokens := make([]Token, 0)
-	for _, tokenAddress := range swapPath {
-		token, err := getTokenInfo(client, chain, tokenAddress)
-		if err != nil {
-			return err
-		}
+	for _, token := range tokens {
 		paths = append(paths, token.Symbol)
-		tokens = append(tokens, token)
 	}
-	printAlignLine(where, router.Name+"  "+strings.Join(paths, " -> "))
-	printAlignLine("", "AmountOutMin  "+formatToken(amoutOutMin.String(), tokens[len(tokens)-1]))
-	if withDetail {
+	printAlignLine(w, where, call.Router.Name+"  "+strings.Join(paths, " -> "))
+	if err := validateSwapPath(chain, call.Path, swapItem); err != nil {
+		printAlignLine(w, "", color.HiYellowString("warning: %s", err))
+	}
+	printAlignLine(w, "", "AmountOutMin  "+d.formatToken(call.AmountOutMin, tokens[len(tokens)-1]))
+	printDeadline(w, call.Deadline, env.deadline)
+	d.printQuote(ctx, w, chain, env.quoteBlock, call, swapItem, tokens[len(tokens)-1])
+	if d.withDetail {
 		for _, token := range tokens {
-			printAlignLine("", alignString(token.Symbol, 7)+token.Address)
+			printAlignLine(w, "", formatTokenDetail(token))
 		}
 	}
 	return nil
 }
 
-func printSwapV3Item(where string, chain *config.ChainInfo, router config.UniswapRouter, swapItem SwapData) error {
-	method, err := xabi.ISwapRouter.MethodById(swapItem.CallData[:4])
-	if err != nil {
-		return err
-	}
-	inputValues, err := method.Inputs.Unpack(swapItem.CallData[4:])
-	if err != nil {
-		return err
-	}
-	res := &SwapV3InputData{}
-	err = method.Inputs.Copy(res, inputValues)
-	if err != nil {
-		return err
-	}
-
-	paths, fees := decodePath(res.ExactInputParams.Path)
-	client, err := ethclient.Dial(chain.Rpc)
+func (d *Decoder) printSwapV3Item(ctx context.Context, w io.Writer, where string, chain *config.ChainInfo, env swapEnv, call *swapCall, swapItem SwapData) error {
+	tokens, err := d.getTokenInfos(ctx, chain, call.Path)
 	if err != nil {
 		return err
 	}
 	pathContent := ""
-	tokens := make([]Token, 0)
-	for i, tokenAddres := range paths {
-		token, err := getTokenInfo(client, chain, tokenAddres)
-		if err != nil {
-			return err
-		}
+	nonStandard := make([]FeeTier, 0)
+	for i, token := range tokens {
 		if i > 0 {
-			pathContent = pathContent + fmt.Sprintf(" --(%.1f%%)--> %s", float32(fees[i-1])/1000, token.Symbol)
+			fee := FeeTier(call.Fees[i-1])
+			feeContent := fee.String()
+			if fee.Label() != "" {
+				feeContent = feeContent + " " + fee.Label()
+			}
+			if !fee.EnabledOn(call.Router) {
+				nonStandard = append(nonStandard, fee)
+			}
+			pathContent = pathContent + fmt.Sprintf(" --(%s)--> %s", feeContent, token.Symbol)
 		} else {
 			pathContent = pathContent + token.Symbol
 		}
-		tokens = append(tokens, token)
 	}
 
-	printAlignLine(where, router.Name+"  "+pathContent)
-	printAlignLine("", "AmountOutMin  "+formatToken(res.ExactInputParams.AmountOutMinimum.String(), tokens[len(tokens)-1]))
-	if withDetail {
+	printAlignLine(w, where, call.Router.Name+"  "+pathContent)
+	if err := validateSwapPath(chain, call.Path, swapItem); err != nil {
+		printAlignLine(w, "", color.HiYellowString("warning: %s", err))
+	}
+	for _, fee := range nonStandard {
+		printAlignLine(w, "", color.HiYellowString("warning: fee tier %d (%s) is not enabled on %s factory", int(fee), fee, call.Router.Name))
+	}
+	printAlignLine(w, "", "AmountOutMin  "+d.formatToken(call.AmountOutMin, tokens[len(tokens)-1]))
+	printDeadline(w, call.Deadline, env.deadline)
+	d.printQuote(ctx, w, chain, env.quoteBlock, call, swapItem, tokens[len(tokens)-1])
+	if d.withDetail {
 		for _, token := range tokens {
-			printAlignLine("", alignString(token.Symbol, 7)+token.Address)
+			printAlignLine(w, "", formatTokenDetail(token))
 		}
 	}
 
 	return nil
 }
 
-func printSoData(soData SoData) error {
+func (d *Decoder) printSoData(ctx context.Context, w io.Writer, soData SoData) error {
 	fromChain := config.GetChainByChainId(int(soData.SourceChainId.Int64()))
 	if nil == fromChain {
 		return errors.New("not found from chain")
 	}
 	toChain := config.GetChainByChainId(int(soData.DestinationChainId.Int64()))
-	if nil == fromChain {
+	if nil == toChain {
 		return errors.New("not found to chain")
 	}
-	fromClient, err := ethclient.Dial(fromChain.Rpc)
-	if err != nil {
-		return err
-	}
-	toClient, err := ethclient.Dial(toChain.Rpc)
-	if err != nil {
-		return err
-	}
-	fromToken, err := getTokenInfo(fromClient, fromChain, soData.SendingAssetId)
+	fromToken, err := d.getTokenInfo(ctx, fromChain, soData.SendingAssetId)
 	if err != nil {
 		return nil
 	}
-	toToken, err := getTokenInfo(toClient, toChain, soData.ReceivingAssetId)
+	toToken, err := d.getTokenInfo(ctx, toChain, soData.ReceivingAssetId)
 	if err != nil {
 		return nil
 	}
 
-	printAlignLine("TransactionId", hex.EncodeToString(soData.TransactionId[:]))
-	printAlignLine("Receiver", soData.Receiver.String())
-	printAlignLine("Router", fmt.Sprintf("%s(%s) -> %s(%s)", fromChain.ChainName, fromToken.Symbol, toChain.ChainName, toToken.Symbol))
-	printAlignLine("SendTokenAddress", soData.SendingAssetId.Hex())
-	printAlignLine("ReceiveTokenAddress", soData.ReceivingAssetId.Hex())
-	printAlignLine("Amount", formatToken(soData.Amount.String(), fromToken))
+	printAlignLine(w, "TransactionId", hex.EncodeToString(soData.TransactionId[:]))
+	printAlignLine(w, "Receiver", soData.Receiver.String())
+	printAlignLine(w, "Router", fmt.Sprintf("%s(%s) -> %s(%s)", fromChain.ChainName, fromToken.Symbol, toChain.ChainName, toToken.Symbol))
+	printAlignLine(w, "SendTokenAddress", strings.TrimSpace(soData.SendingAssetId.Hex()+"  "+assetLabel(fromChain, soData.SendingAssetId)))
+	printAlignLine(w, "ReceiveTokenAddress", strings.TrimSpace(soData.ReceivingAssetId.Hex()+"  "+assetLabel(toChain, soData.ReceivingAssetId)))
+	printAlignLine(w, "Amount", d.formatToken(soData.Amount, fromToken))
 	return nil
 }
 
-func formatToken(amount string, token Token) string {
-	a, _ := decimal.NewFromString(amount)
-	f, _ := a.Div(decimal.NewFromBigInt(big.NewInt(1), int32(token.Decimals))).Float64()
-	return fmt.Sprintf("%.15f %s", f, token.Symbol)
+func (d *Decoder) formatToken(amount *big.Int, token Token) string {
+	return d.amountFormat.Format(amount, token)
 }
 
-func printTxBaseInfo(chain *config.ChainInfo, tx *types.Transaction) {
-	printAlignLine("Tx Base Info", "")
-	printAlignLine("Gas Limit", strconv.Itoa(int(tx.Gas())))
-	printAlignLine("Gas Price", tx.GasPrice().String())
-	printAlignLine("Value", formatToken(tx.Value().String(), Token{
-		Decimals: 18,
-		Symbol:   chain.CurrancySymbol,
-		Name:     chain.CurrancySymbol,
-	}))
-}
-
-func printAlignLine(left string, content string) {
+func printAlignLine(w io.Writer, left string, content string) {
 	left = alignString(left, alignment)
-	fmt.Println(left + color.HiBlueString("%s", content))
+	fmt.Fprintln(w, left+color.HiBlueString("%s", content))
 }
 
 func alignString(s string, l int) string {
@@ -427,45 +506,12 @@ func alignString(s string, l int) string {
 	return s
 }
 
-func printReceipt(receipt *types.Receipt, chain *config.ChainInfo, hash common.Hash) {
-	if nil == receipt {
-		return
-	}
-	printAlignLine("Status", strconv.Itoa(int(receipt.Status)))
-
-	if receipt.Status == 0 {
-		ctx := context.Background()
-		var r *MyReceipt
-		rpcClient, _ := rpc.DialContext(context.Background(), chain.Rpc)
-		err := rpcClient.CallContext(ctx, &r, "eth_getTransactionReceipt", hash)
-		if err != nil {
-			printError("eth_getTransactionReceipt", err)
-		}
-		returnData, err := hex.DecodeString(strings.TrimPrefix(r.ReturnData, "0x"))
-		if err != nil {
-			printError("DecodeString", err)
-		}
-		// 4 bytes function
-		// 32 bytes offset
-		// 32 bytes length
-		// data
-		if len(returnData) < 68 {
-			return
-		}
-		lengthData := big.NewInt(0).SetBytes(common.TrimLeftZeroes(returnData[36:68])).Int64()
-		if len(returnData) < int(68+lengthData) {
-			return
-		}
-		printAlignLine("ErrorInfo", string(returnData[68:68+lengthData]))
-	}
-}
-
-func printLine() {
-	fmt.Println("==========================================================")
+func printLine(w io.Writer) {
+	fmt.Fprintln(w, "==========================================================")
 }
 
-func printError(where string, err error) {
-	fmt.Print(color.HiRedString("%s err: %s\n", where, err))
+func printError(w io.Writer, where string, err error) {
+	fmt.Fprint(w, color.HiRedString("%s err: %s\n", where, err))
 }
 
 const (
@@ -493,7 +539,11 @@ func encodePath(path []common.Address, fees []int) (encoded []byte, err error) {
 	return
 }
 
-func decodePath(pathByte []byte) ([]common.Address, []int) {
+// decodePath decode swap v3 path, path 格式为 address (fee address)+，长度不合法时返回错误
+func decodePath(pathByte []byte) ([]common.Address, []int, error) {
+	if len(pathByte) < DataSize || (len(pathByte)-AddrSize)%Offset != 0 {
+		return nil, nil, fmt.Errorf("invalid v3 path length %d", len(pathByte))
+	}
 	paths := make([]common.Address, 0)
 	fees := make([]int, 0)
 	// 20 字节 address
@@ -507,5 +557,29 @@ func decodePath(pathByte []byte) ([]common.Address, []int) {
 			i += FeeSize
 		}
 	}
-	return paths, fees
+	return paths, fees, nil
+}
+
+// validateSwapPath 检查 swap path 首尾 token 与 SwapData 的 SendingAssetId/ReceivingAssetId 一致
+// 0 地址代表原生币，由 router 包装为链配置的 wrapped native token
+func validateSwapPath(chain *config.ChainInfo, path []common.Address, swapItem SwapData) error {
+	if len(path) < 2 {
+		return fmt.Errorf("swap path has %d tokens, at least 2 required", len(path))
+	}
+	first, last := path[0], path[len(path)-1]
+	if expect := swapPathAsset(chain, swapItem.SendingAssetId); expect != (common.Address{}) && first != expect {
+		return fmt.Errorf("path starts with %s, but SendingAssetId is %s", first, swapItem.SendingAssetId)
+	}
+	if expect := swapPathAsset(chain, swapItem.ReceivingAssetId); expect != (common.Address{}) && last != expect {
+		return fmt.Errorf("path ends with %s, but ReceivingAssetId is %s", last, swapItem.ReceivingAssetId)
+	}
+	return nil
+}
+
+// swapPathAsset 资产在 swap path 中对应的 token，原生币对应 wrapped native token，未配置时返回 0 地址
+func swapPathAsset(chain *config.ChainInfo, asset common.Address) common.Address {
+	if isZeroAddress(asset) {
+		return wrappedNativeAddress(chain)
+	}
+	return asset
 }
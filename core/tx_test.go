@@ -5,6 +5,8 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/xiang-xx/oparse/config"
+
 	"github.com/ethereum/go-ethereum/common"
 )
 
@@ -39,7 +41,10 @@ func Test_decodePath(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, got1 := decodePath(tt.args.pathByte)
+			got, got1, err := decodePath(tt.args.pathByte)
+			if err != nil {
+				t.Fatalf("decodePath() err = %v", err)
+			}
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("decodePath() got = %v, want %v", got, tt.want)
 			}
@@ -49,3 +54,119 @@ func Test_decodePath(t *testing.T) {
 		})
 	}
 }
+
+func Test_decodePath_invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		pathByte []byte
+	}{
+		{name: "empty", pathByte: nil},
+		{name: "single address", pathByte: make([]byte, AddrSize)},
+		{name: "truncated fee", pathByte: make([]byte, AddrSize+2)},
+		{name: "truncated address", pathByte: make([]byte, DataSize-1)},
+		{name: "trailing fee", pathByte: make([]byte, DataSize+FeeSize)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodePath(tt.pathByte); err == nil {
+				t.Errorf("decodePath() expect error for length %d", len(tt.pathByte))
+			}
+		})
+	}
+}
+
+func FuzzDecodePath(f *testing.F) {
+	seed, _ := encodePath([]common.Address{
+		common.HexToAddress("0xff970a61a04b1ca14834a43f5de4533ebddb5cc8"),
+		common.HexToAddress("0xfd086bc7cd5c481dcc9c85ebe478a1c0b69fcbb9"),
+	}, []int{500})
+	f.Add(seed)
+	f.Add(seed[:len(seed)-1])
+	f.Add([]byte{})
+	f.Fuzz(func(t *testing.T, pathByte []byte) {
+		paths, fees, err := decodePath(pathByte)
+		if err != nil {
+			return
+		}
+		encoded, err := encodePath(paths, fees)
+		if err != nil {
+			t.Fatalf("encodePath() err = %v", err)
+		}
+		if !reflect.DeepEqual(encoded, pathByte) {
+			t.Errorf("encodePath(decodePath(%x)) = %x", pathByte, encoded)
+		}
+	})
+}
+
+func Test_validateSwapPath(t *testing.T) {
+	usdc := common.HexToAddress("0xff970a61a04b1ca14834a43f5de4533ebddb5cc8")
+	usdt := common.HexToAddress("0xfd086bc7cd5c481dcc9c85ebe478a1c0b69fcbb9")
+	weth := common.HexToAddress("0x82af49447d8a07e3bd95bd0d56f35241523fbab1")
+	chain := &config.ChainInfo{WETH: weth.Hex()}
+	tests := []struct {
+		name     string
+		path     []common.Address
+		swapItem SwapData
+		wantErr  bool
+	}{
+		{
+			name:     "match",
+			path:     []common.Address{usdc, usdt},
+			swapItem: SwapData{SendingAssetId: usdc, ReceivingAssetId: usdt},
+		},
+		{
+			name:     "native sending asset",
+			path:     []common.Address{weth, usdt},
+			swapItem: SwapData{ReceivingAssetId: usdt},
+		},
+		{
+			name:     "native sending asset not wrapped",
+			path:     []common.Address{usdc, usdt},
+			swapItem: SwapData{ReceivingAssetId: usdt},
+			wantErr:  true,
+		},
+		{
+			name:     "first token mismatch",
+			path:     []common.Address{usdt, usdc},
+			swapItem: SwapData{SendingAssetId: usdc, ReceivingAssetId: usdc},
+			wantErr:  true,
+		},
+		{
+			name:     "last token mismatch",
+			path:     []common.Address{usdc, weth},
+			swapItem: SwapData{SendingAssetId: usdc, ReceivingAssetId: usdt},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateSwapPath(chain, tt.path, tt.swapItem); (err != nil) != tt.wantErr {
+				t.Errorf("validateSwapPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFeeTier_String(t *testing.T) {
+	tests := []struct {
+		fee   FeeTier
+		want  string
+		label string
+	}{
+		{fee: 100, want: "0.01%", label: "lowest"},
+		{fee: 500, want: "0.05%", label: "low"},
+		{fee: 3000, want: "0.3%", label: "medium"},
+		{fee: 10000, want: "1%", label: "high"},
+		{fee: 2500, want: "0.25%", label: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.fee.String(); got != tt.want {
+				t.Errorf("String() = %v, want %v", got, tt.want)
+			}
+			if got := tt.fee.Label(); got != tt.label {
+				t.Errorf("Label() = %v, want %v", got, tt.label)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xiang-xx/oparse/config"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// MyReceipt eth_getTransactionReceipt 返回的、types.Receipt 中没有的字段
+type MyReceipt struct {
+	// types.Receipt
+	ReturnCode        string       `json:"returnCode"`
+	ReturnData        string       `json:"returnData"`
+	EffectiveGasPrice *hexutil.Big `json:"effectiveGasPrice"`
+
+	// Optimism，l1 数据费用在执行费用之外单独收取
+	L1Fee       *hexutil.Big `json:"l1Fee"`
+	L1GasUsed   *hexutil.Big `json:"l1GasUsed"`
+	L1GasPrice  *hexutil.Big `json:"l1GasPrice"`
+	L1FeeScalar string       `json:"l1FeeScalar"`
+	// Arbitrum，gasUsed 中包含支付 l1 数据费用的部分
+	GasUsedForL1 *hexutil.Big `json:"gasUsedForL1"`
+}
+
+var gweiToken = Token{
+	Name:     "gwei",
+	Symbol:   "gwei",
+	Decimals: 9,
+}
+
+var txTypeNames = map[uint8]string{
+	types.LegacyTxType:     "legacy",
+	types.AccessListTxType: "access list (EIP-2930)",
+	types.DynamicFeeTxType: "dynamic fee (EIP-1559)",
+}
+
+func (d *Decoder) getRawReceipt(ctx context.Context, chain *config.ChainInfo, hash common.Hash) (*MyReceipt, error) {
+	rpcClient, err := d.rpcClient(chain)
+	if err != nil {
+		return nil, err
+	}
+	var r *MyReceipt
+	callCtx, cancel := d.callContext(ctx)
+	defer cancel()
+	err = rpcClient.CallContext(callCtx, &r, "eth_getTransactionReceipt", hash)
+	return r, err
+}
+
+// printTxBaseInfo 输出交易基础信息，receipt、rawReceipt、header 为 nil 时（交易未打包或获取失败）不输出对应字段
+func (d *Decoder) printTxBaseInfo(w io.Writer, chain *config.ChainInfo, tx *types.Transaction, receipt *types.Receipt, rawReceipt *MyReceipt, header *types.Header) {
+	printAlignLine(w, "Tx Base Info", "")
+	printAlignLine(w, "Type", strconv.Itoa(int(tx.Type()))+" "+txTypeNames[tx.Type()])
+	printAlignLine(w, "Nonce", strconv.FormatUint(tx.Nonce(), 10))
+	from, err := types.Sender(types.LatestSignerForChainID(big.NewInt(int64(chain.ChainId))), tx)
+	if err != nil {
+		printError(w, "recover sender", err)
+	} else {
+		printAlignLine(w, "From", from.Hex())
+	}
+	if tx.To() == nil {
+		printAlignLine(w, "To", "contract creation")
+	} else {
+		printAlignLine(w, "To", tx.To().Hex())
+	}
+	printAlignLine(w, "Value", d.formatToken(tx.Value(), nativeToken(chain)))
+	printAlignLine(w, "Gas Limit", strconv.FormatUint(tx.Gas(), 10))
+	if tx.Type() == types.DynamicFeeTxType {
+		printAlignLine(w, "MaxFeePerGas", d.formatToken(tx.GasFeeCap(), gweiToken))
+		printAlignLine(w, "MaxPriorityFeePerGas", d.formatToken(tx.GasTipCap(), gweiToken))
+	} else {
+		printAlignLine(w, "Gas Price", d.formatToken(tx.GasPrice(), gweiToken))
+	}
+
+	if nil == receipt {
+		printAlignLine(w, "Block", "pending")
+		return
+	}
+	gasPrice := effectiveGasPrice(tx, rawReceipt, header)
+	printAlignLine(w, "EffectiveGasPrice", d.formatToken(gasPrice, gweiToken))
+	printAlignLine(w, "Gas Used", strconv.FormatUint(receipt.GasUsed, 10))
+	d.printTxFee(w, chain, gasPrice, receipt, rawReceipt)
+	block := receipt.BlockNumber.String()
+	if header != nil {
+		block = block + "  " + time.Unix(int64(header.Time), 0).UTC().Format(time.RFC3339)
+	}
+	printAlignLine(w, "Block", block)
+}
+
+// printTxFee 输出交易手续费，L2 链上分别输出 L1 数据费用和 L2 执行费用
+func (d *Decoder) printTxFee(w io.Writer, chain *config.ChainInfo, gasPrice *big.Int, receipt *types.Receipt, rawReceipt *MyReceipt) {
+	native := nativeToken(chain)
+	gasUsed := new(big.Int).SetUint64(receipt.GasUsed)
+	fee := new(big.Int).Mul(gasPrice, gasUsed)
+	switch {
+	case rawReceipt != nil && rawReceipt.L1Fee != nil:
+		// Optimism: 总费用 = L2 执行费用 + l1Fee
+		l1Fee := rawReceipt.L1Fee.ToInt()
+		printAlignLine(w, "Tx Fee", d.formatToken(new(big.Int).Add(fee, l1Fee), native))
+		printAlignLine(w, "", alignString("L2 Fee", 8)+d.formatToken(fee, native))
+		l1Content := alignString("L1 Fee", 8) + d.formatToken(l1Fee, native)
+		if rawReceipt.L1GasUsed != nil && rawReceipt.L1GasPrice != nil {
+			l1Content = l1Content + fmt.Sprintf("  (l1GasUsed %s, l1GasPrice %s", rawReceipt.L1GasUsed.ToInt(), d.formatToken(rawReceipt.L1GasPrice.ToInt(), gweiToken))
+			if rawReceipt.L1FeeScalar != "" {
+				l1Content = l1Content + ", scalar " + rawReceipt.L1FeeScalar
+			}
+			l1Content = l1Content + ")"
+		}
+		printAlignLine(w, "", l1Content)
+	case rawReceipt != nil && rawReceipt.GasUsedForL1 != nil:
+		// Arbitrum: gasUsed 中 gasUsedForL1 部分用于支付 L1 数据费用
+		l1Gas := rawReceipt.GasUsedForL1.ToInt()
+		l2Gas := new(big.Int).Sub(gasUsed, l1Gas)
+		printAlignLine(w, "Tx Fee", d.formatToken(fee, native))
+		printAlignLine(w, "", alignString("L2 Fee", 8)+d.formatToken(new(big.Int).Mul(l2Gas, gasPrice), native)+"  (gas "+l2Gas.String()+")")
+		printAlignLine(w, "", alignString("L1 Fee", 8)+d.formatToken(new(big.Int).Mul(l1Gas, gasPrice), native)+"  (gasUsedForL1 "+l1Gas.String()+")")
+	default:
+		printAlignLine(w, "Tx Fee", d.formatToken(fee, native))
+	}
+}
+
+// effectiveGasPrice 优先使用 receipt 中的 effectiveGasPrice，没有时根据 base fee 计算
+func effectiveGasPrice(tx *types.Transaction, rawReceipt *MyReceipt, header *types.Header) *big.Int {
+	if rawReceipt != nil && rawReceipt.EffectiveGasPrice != nil {
+		return rawReceipt.EffectiveGasPrice.ToInt()
+	}
+	if tx.Type() == types.DynamicFeeTxType && header != nil && header.BaseFee != nil {
+		return math.BigMin(new(big.Int).Add(tx.GasTipCap(), header.BaseFee), tx.GasFeeCap())
+	}
+	return tx.GasPrice()
+}
+
+func printReceipt(w io.Writer, receipt *types.Receipt, rawReceipt *MyReceipt) {
+	if nil == receipt {
+		return
+	}
+	printAlignLine(w, "Status", strconv.Itoa(int(receipt.Status)))
+
+	// 只有部分链的 receipt 返回 ReturnData
+	if receipt.Status == 0 && rawReceipt != nil && rawReceipt.ReturnData != "" && rawReceipt.ReturnData != "0x" {
+		returnData, err := hex.DecodeString(strings.TrimPrefix(rawReceipt.ReturnData, "0x"))
+		if err != nil {
+			printError(w, "DecodeString", err)
+			return
+		}
+		printAlignLine(w, "ErrorInfo", decodeRevert(returnData))
+	}
+}
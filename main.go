@@ -1,49 +1,230 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"sync"
+	"math/big"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/xiang-xx/oparse/config"
 	"github.com/xiang-xx/oparse/core"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const (
+	exitSuccess  = 0
+	exitReverted = 1
+	exitNotFound = 2
+	exitRpcError = 3
+	exitUsage    = 4
+	exitLintFail = 5
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "routes" {
+		os.Exit(runRoutes(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "lint" {
+		os.Exit(runLint(os.Args[2:]))
+	}
+	os.Exit(run(os.Args[1:]))
+}
+
+// runRoutes oparse routes -from eth -to avax，列出两条链之间 Stargate 支持的 pool 路径
+func runRoutes(args []string) int {
+	fs := flag.NewFlagSet("routes", flag.ContinueOnError)
+	from := fs.String("from", "", "source chain name, eg: bsc,ethereum,eth,op,avax")
+	to := fs.String("to", "", "destination chain name")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *from == "" || *to == "" {
+		fmt.Println("please input chains, -from chain -to chain")
+		return exitUsage
+	}
+	fromChain := config.GetChainByName(*from)
+	if nil == fromChain {
+		fmt.Printf("unsupport chain: %s\n", *from)
+		return exitUsage
+	}
+	toChain := config.GetChainByName(*to)
+	if nil == toChain {
+		fmt.Printf("unsupport chain: %s\n", *to)
+		return exitUsage
+	}
+	core.PrintStargateRoutes(os.Stdout, fromChain, toChain)
+	return exitSuccess
+}
+
+// runLint oparse lint -c eth -input 0x...，在签名前检查 SoDiamond calldata，有 error 级别的检查结果时返回 5，
+// -simulate 时模拟执行，revert 时返回 1，rpc 出错返回 3
+func runLint(args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	c := fs.String("c", "", "chain name the tx will be sent on, eg: bsc,ethereum,eth,op,avax")
+	input := fs.String("input", "", "SoDiamond calldata in hex")
+	from := fs.String("from", "", "sender address")
+	value := fs.String("value", "0", "tx value in wei")
+	rpcTimeout := fs.Duration("rpc-timeout", 15*time.Second, "timeout for each rpc request, 0 means no limit")
+	simulate := fs.Bool("simulate", false, "simulate the calldata with eth_call and estimate gas")
+	block := fs.Int64("block", 0, "block number to simulate at, 0 means latest")
+	rpcUrl := fs.String("rpc", "", "rpc url to simulate with instead of the chain default, eg: a local dev node")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	chain := config.GetChainByName(*c)
+	if nil == chain {
+		fmt.Printf("unsupport chain: %s\n", *c)
+		return exitUsage
+	}
+	if *rpcUrl != "" {
+		chain.Rpc = *rpcUrl
+	}
+	// 从零地址模拟时 ERC20 transferFrom 必然 revert，结果没有意义
+	if *simulate && *from == "" {
+		fmt.Println("-simulate requires -from, the sender that holds the tokens and approved SoDiamond")
+		return exitUsage
+	}
+	calldata, err := hexutil.Decode(*input)
+	if err != nil {
+		fmt.Printf("invalid input: %s\n", err)
+		return exitUsage
+	}
+	sender := common.Address{}
+	if *from != "" {
+		if !common.IsHexAddress(*from) {
+			fmt.Printf("invalid from address: %s\n", *from)
+			return exitUsage
+		}
+		sender = common.HexToAddress(*from)
+	}
+	txValue, ok := new(big.Int).SetString(*value, 0)
+	if !ok || txValue.Sign() < 0 {
+		fmt.Printf("invalid value: %s\n", *value)
+		return exitUsage
+	}
+
+	decoder := core.NewDecoder(core.Options{CallTimeout: *rpcTimeout})
+	defer decoder.Close()
+	findings := decoder.LintCalldata(context.Background(), os.Stdout, chain, sender, txValue, calldata)
+	code := exitSuccess
+	if *simulate {
+		var blockNumber *big.Int
+		if *block > 0 {
+			blockNumber = big.NewInt(*block)
+		}
+		result, err := decoder.SimulateCalldata(context.Background(), os.Stdout, chain, sender, txValue, calldata, blockNumber)
+		if err != nil {
+			fmt.Printf("simulate error: %s\n", err)
+			code = exitRpcError
+		} else if result.Reverted {
+			code = exitReverted
+		}
+	}
+	if core.HasErrors(findings) {
+		return exitLintFail
+	}
+	return code
+}
+
+// run oparse -h txhash，在所有链（或 -c 指定的链）上查询并解析交易
+func run(args []string) int {
 	config.GetChainByStargateChainId(1)
 
-	h := flag.String("h", "", "tx hash")
-	c := flag.String("c", "", "chain name, eg: bsc,ethereum,eth,op,avax")
-	d := flag.Bool("d", true, "with detail info")
-	flag.Parse()
+	fs := flag.NewFlagSet("oparse", flag.ContinueOnError)
+	h := fs.String("h", "", "tx hash")
+	c := fs.String("c", "", "chain name, eg: bsc,ethereum,eth,op,avax")
+	d := fs.Bool("d", true, "with detail info")
+	tokenList := fs.String("tokenlist", "", "local token list files in Uniswap format, separated by comma")
+	timeout := fs.Duration("timeout", time.Minute, "timeout for the whole decode, 0 means no limit")
+	rpcTimeout := fs.Duration("rpc-timeout", 15*time.Second, "timeout for each rpc request, 0 means no limit")
+	precision := fs.Int("precision", 0, "max decimal places of token amounts, 0 means full precision")
+	thousands := fs.Bool("thousands", false, "use thousands separators in token amounts")
+	raw := fs.Bool("raw", false, "show raw integer token amounts alongside")
+	dstTx := fs.String("dst-tx", "", "destination tx hash of a Stargate swap, to inspect its gas, delivered amount and cached payload")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
 
 	if nil == h || *h == "" {
-		panic("please input tx hash, -h txhash")
+		fmt.Println("please input tx hash, -h txhash")
+		return exitUsage
 	}
 
+	if *tokenList != "" {
+		for _, path := range strings.Split(*tokenList, ",") {
+			err := core.LoadTokenList(path)
+			if err != nil {
+				fmt.Printf("load token list: %v\n", err)
+				return exitUsage
+			}
+		}
+	}
+
+	decoder := core.NewDecoder(core.Options{
+		WithDetail:  *d,
+		CallTimeout: *rpcTimeout,
+		AmountFormat: core.AmountFormat{
+			Precision: *precision,
+			Thousands: *thousands,
+			ShowRaw:   *raw,
+		},
+	})
+	defer decoder.Close()
+
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	chains := make([]config.ChainInfo, 0)
 	if nil == c || *c == "" {
 		// 从所有链上进行查询
-		allChain := config.GetAllChains()
-		wg := sync.WaitGroup{}
-		for _, chain := range allChain {
+		for _, chain := range config.GetAllChains() {
 			if chain.Rpc == "" {
 				continue
 			}
-			wg.Add(1)
-			go func(tmpChain config.ChainInfo) {
-				defer func() {
-					wg.Done()
-				}()
-				core.ParseTxOnChain(&tmpChain, *h, *d)
-			}(chain)
-		}
-		wg.Wait()
+			chains = append(chains, chain)
+		}
 	} else {
 		chain := config.GetChainByName(*c)
 		if nil == chain {
 			fmt.Printf("unsupport chain: %s\n", *c)
-			return
+			return exitUsage
+		}
+		chains = append(chains, *chain)
+	}
+
+	result := decoder.SearchTx(ctx, os.Stdout, chains, *h, *dstTx)
+	core.PrintSearchSummary(os.Stdout, result)
+	return exitCode(result)
+}
+
+// exitCode 查到交易且执行成功（或未打包）返回 0，执行失败返回 1，所有链上都未查到返回 2，
+// 未查到且有 rpc 出错或查到交易但获取 receipt 出错返回 3
+func exitCode(result core.SearchResult) int {
+	found := result.Found()
+	if len(found) == 0 {
+		if len(result.Errors()) > 0 {
+			return exitRpcError
+		}
+		return exitNotFound
+	}
+	for _, r := range found {
+		if r.Reverted() {
+			return exitReverted
+		}
+	}
+	for _, r := range found {
+		if r.Err != nil {
+			return exitRpcError
 		}
-		core.ParseTxOnChain(chain, *h, *d)
 	}
+	return exitSuccess
 }
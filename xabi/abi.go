@@ -22,12 +22,32 @@ var iUniswapV2Router02AVAX []byte
 //go:embed ERC20.json
 var erc20 []byte
 
+//go:embed Multicall3.json
+var multicall3 []byte
+
+//go:embed IQuoter.json
+var iQuoter []byte
+
+//go:embed IStargateRouter.json
+var iStargateRouter []byte
+
+//go:embed IStargatePool.json
+var iStargatePool []byte
+
+//go:embed ILayerZeroUltraLightNodeV2.json
+var iLayerZeroUltraLightNodeV2 []byte
+
 var (
-	SoDiamond              abi.ABI
-	ISwapRouter            abi.ABI
-	IUniswapV2Router02     abi.ABI
-	IUniswapV2Router02AVAX abi.ABI
-	ERC20                  abi.ABI
+	SoDiamond                  abi.ABI
+	ISwapRouter                abi.ABI
+	IUniswapV2Router02         abi.ABI
+	IUniswapV2Router02AVAX     abi.ABI
+	ERC20                      abi.ABI
+	Multicall3                 abi.ABI
+	IQuoter                    abi.ABI
+	IStargateRouter            abi.ABI
+	IStargatePool              abi.ABI
+	ILayerZeroUltraLightNodeV2 abi.ABI
 )
 
 func init() {
@@ -52,4 +72,24 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
+	Multicall3, err = abi.JSON(bytes.NewReader(multicall3))
+	if err != nil {
+		panic(err)
+	}
+	IQuoter, err = abi.JSON(bytes.NewReader(iQuoter))
+	if err != nil {
+		panic(err)
+	}
+	IStargateRouter, err = abi.JSON(bytes.NewReader(iStargateRouter))
+	if err != nil {
+		panic(err)
+	}
+	IStargatePool, err = abi.JSON(bytes.NewReader(iStargatePool))
+	if err != nil {
+		panic(err)
+	}
+	ILayerZeroUltraLightNodeV2, err = abi.JSON(bytes.NewReader(iLayerZeroUltraLightNodeV2))
+	if err != nil {
+		panic(err)
+	}
 }